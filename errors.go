@@ -0,0 +1,30 @@
+package goobs
+
+import "errors"
+
+var (
+	ErrInvalidConfig = errors.New("invalid observability config")
+	ErrLoggingSetup  = errors.New("logging setup failed")
+	ErrTracingSetup  = errors.New("tracing setup failed")
+	ErrMetricsSetup  = errors.New("metrics setup failed")
+)
+
+// SetupError is returned by the initializers. Stage is one of the Err*Setup or
+// ErrInvalidConfig sentinels, so callers can use errors.Is to decide whether to
+// degrade or exit, and errors.As to inspect the underlying cause.
+type SetupError struct {
+	Stage error
+	Err   error
+}
+
+func (e *SetupError) Error() string {
+	return e.Stage.Error() + ": " + e.Err.Error()
+}
+
+func (e *SetupError) Unwrap() []error {
+	return []error{e.Stage, e.Err}
+}
+
+func newSetupError(stage, err error) error {
+	return &SetupError{Stage: stage, Err: err}
+}
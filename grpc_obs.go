@@ -1,11 +1,6 @@
 package goobs
 
-import (
-	"github.com/todesdev/go-obs/internal/logging"
-	"github.com/todesdev/go-obs/internal/observer"
-	"github.com/todesdev/go-obs/internal/tracing"
-	"go.uber.org/zap"
-)
+import "errors"
 
 type GRPCObserverConfig struct {
 	ServiceName      string
@@ -13,63 +8,44 @@ type GRPCObserverConfig struct {
 	Region           string
 	LogLevel         string
 	OTLPGRPCEndpoint string
+	OTLPHTTPEndpoint string
+	OTLPHTTPEncoding string
+	OTLPConnection   OTLPConnectionConfig
 	TracingEnabled   bool
+	Sampling         SamplingConfig
+	RequestIDHeader  string
 }
 
+// InitializeGRPCObserver sets up logging and tracing only. It is kept for existing
+// callers; Initialize without a FiberApp covers the same use case and adds metrics.
 func InitializeGRPCObserver(cfg *GRPCObserverConfig) error {
 	validatedConfig, err := validateGRPCObserverConfig(cfg)
 	if err != nil {
-		return err
+		return newSetupError(ErrInvalidConfig, err)
 	}
 
-	logging.Setup(validatedConfig.Region, validatedConfig.ServiceName, validatedConfig.ServiceVersion, validatedConfig.LogLevel)
-	logger := logging.LoggerWithProcess("observability:initialize")
-	logger.Info("Logger setup complete")
-
-	if validatedConfig.TracingEnabled {
-		observer.SetTracingEnabled(true)
-		res, err := registerResource(validatedConfig.ServiceName, validatedConfig.ServiceVersion, validatedConfig.Region)
-		if err != nil {
-			logger.Error("Failed to register resource", zap.Error(err))
-			return err
-		}
-
-		if validatedConfig.OTLPGRPCEndpoint != "" {
-			err := tracing.SetupOtlpGrpcTracer(validatedConfig.OTLPGRPCEndpoint, validatedConfig.ServiceName, res)
-			if err != nil {
-				logger.Error("Failed to setup OTLP GRPC tracer", zap.Error(err))
-				logger.Info("Switching to STDOUT tracer")
-				err = tracing.SetupStdOutTracer(validatedConfig.ServiceName, res)
-				if err != nil {
-					logger.Error("Failed to setup STDOUT tracer", zap.Error(err))
-					return err
-				}
-
-				logger.Info("Tracing exporter set to STDOUT")
-			}
-
-			logger.Info("Tracing exporter set to OTLP GRPC")
-		} else {
-			err := tracing.SetupStdOutTracer(validatedConfig.ServiceName, res)
-			if err != nil {
-				logger.Error("Failed to setup STDOUT tracer", zap.Error(err))
-				return err
-			}
-
-			logger.Info("Tracing exporter set to STDOUT")
-		}
-
-		logger.Info("Tracing setup complete")
-	} else {
-		logger.Warn("Tracing is disabled")
-	}
-
-	return nil
+	return Initialize(&Config{
+		ServiceName:      validatedConfig.ServiceName,
+		ServiceVersion:   validatedConfig.ServiceVersion,
+		Region:           validatedConfig.Region,
+		LogLevel:         validatedConfig.LogLevel,
+		OTLPGRPCEndpoint: validatedConfig.OTLPGRPCEndpoint,
+		OTLPHTTPEndpoint: validatedConfig.OTLPHTTPEndpoint,
+		OTLPHTTPEncoding: validatedConfig.OTLPHTTPEncoding,
+		OTLPConnection:   validatedConfig.OTLPConnection,
+		TracingEnabled:   validatedConfig.TracingEnabled,
+		Sampling:         validatedConfig.Sampling,
+		RequestIDHeader:  validatedConfig.RequestIDHeader,
+	})
 }
 
 func validateGRPCObserverConfig(cfg *GRPCObserverConfig) (*GRPCObserverConfig, error) {
 	var validatedConfig GRPCObserverConfig
 
+	if cfg == nil {
+		return nil, errors.New("config is nil")
+	}
+
 	validatedConfig.ServiceName = cfg.ServiceName
 	validatedConfig.ServiceVersion = cfg.ServiceVersion
 	validatedConfig.Region = cfg.Region
@@ -77,6 +53,11 @@ func validateGRPCObserverConfig(cfg *GRPCObserverConfig) (*GRPCObserverConfig, e
 	validatedConfig.TracingEnabled = cfg.TracingEnabled
 
 	validatedConfig.OTLPGRPCEndpoint = cfg.OTLPGRPCEndpoint
+	validatedConfig.OTLPHTTPEndpoint = cfg.OTLPHTTPEndpoint
+	validatedConfig.OTLPHTTPEncoding = cfg.OTLPHTTPEncoding
+	validatedConfig.OTLPConnection = cfg.OTLPConnection
+	validatedConfig.Sampling = cfg.Sampling
+	validatedConfig.RequestIDHeader = cfg.RequestIDHeader
 
 	return &validatedConfig, nil
 }
@@ -0,0 +1,156 @@
+package http_client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	httpclientcollector "github.com/todesdev/go-obs/internal/metrics/http_client_collector"
+	"github.com/todesdev/go-obs/internal/observer"
+	"github.com/todesdev/go-obs/internal/requestid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.uber.org/zap"
+)
+
+// Transport is an http.RoundTripper that traces outbound requests with a client span,
+// propagates the trace context and request ID in the request headers and records
+// client metrics.
+type Transport struct {
+	base http.RoundTripper
+}
+
+// NewTransport wraps base, or http.DefaultTransport when base is nil.
+func NewTransport(base http.RoundTripper) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &Transport{base: base}
+}
+
+// NewClient returns an http.Client using a Transport around http.DefaultTransport.
+func NewClient() *http.Client {
+	return &http.Client{Transport: NewTransport(nil)}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	startTime := time.Now()
+	host := req.URL.Host
+
+	obs := observer.ClientObserver(req.Context(), getProcessName(req.Method, host))
+	defer obs.End()
+	obs.SetAttributes(methodAttributes(req.Method)...)
+
+	// A RoundTripper must not modify the caller's request, so inject into a clone.
+	outReq := req.Clone(obs.Ctx())
+	otel.GetTextMapPropagator().Inject(obs.Ctx(), propagation.HeaderCarrier(outReq.Header))
+	if id := requestid.FromContext(obs.Ctx()); id != "" && outReq.Header.Get(requestid.Header()) == "" {
+		outReq.Header.Set(requestid.Header(), id)
+	}
+
+	resp, err := t.base.RoundTrip(outReq)
+	elapsedTime := time.Since(startTime)
+
+	if err != nil {
+		recordMetrics(req.Method, host, httpclientcollector.HttpClientErrorStatus, elapsedTime)
+		obs.SetStatus(codes.Error, err.Error())
+		obs.RecordErrorWithLogging("HTTP client request failed", err, zap.String("method", req.Method), zap.String("url", req.URL.Redacted()), zap.Duration("elapsedTime", elapsedTime))
+		return nil, err
+	}
+
+	recordMetrics(req.Method, host, strconv.Itoa(resp.StatusCode), elapsedTime)
+	setResponseStatus(obs, resp.StatusCode)
+	if resp.StatusCode >= http.StatusInternalServerError {
+		obs.LogWarning("HTTP client request returned server error", zap.String("method", req.Method), zap.String("url", req.URL.Redacted()), zap.Int("statusCode", resp.StatusCode), zap.Duration("elapsedTime", elapsedTime))
+	}
+
+	return resp, nil
+}
+
+// AgentBytes is the traced counterpart of fiber.Agent.Bytes. It parses the agent,
+// injects the trace context from ctx into the request headers and records the same
+// span, metrics and failure logs as Transport. Like Bytes, the agent is released.
+func AgentBytes(ctx context.Context, a *fiber.Agent) (int, []byte, []error) {
+	startTime := time.Now()
+
+	if err := a.Parse(); err != nil {
+		fiber.ReleaseAgent(a)
+		return 0, nil, []error{err}
+	}
+
+	req := a.Request()
+	method := string(req.Header.Method())
+	host := string(req.URI().Host())
+
+	obs := observer.ClientObserver(ctx, getProcessName(method, host))
+	defer obs.End()
+	obs.SetAttributes(methodAttributes(method)...)
+
+	carrier := make(propagation.HeaderCarrier)
+	otel.GetTextMapPropagator().Inject(obs.Ctx(), carrier)
+	for k := range carrier {
+		a.Set(k, carrier.Get(k))
+	}
+	if id := requestid.FromContext(obs.Ctx()); id != "" && len(a.Request().Header.Peek(requestid.Header())) == 0 {
+		a.Set(requestid.Header(), id)
+	}
+
+	code, body, errs := a.Bytes()
+	elapsedTime := time.Since(startTime)
+
+	if len(errs) > 0 {
+		recordMetrics(method, host, httpclientcollector.HttpClientErrorStatus, elapsedTime)
+		obs.SetStatus(codes.Error, errs[0].Error())
+		obs.RecordErrorWithLogging("HTTP client request failed", errs[0], zap.String("method", method), zap.String("host", host), zap.Errors("errors", errs), zap.Duration("elapsedTime", elapsedTime))
+		return code, body, errs
+	}
+
+	recordMetrics(method, host, strconv.Itoa(code), elapsedTime)
+	setResponseStatus(obs, code)
+	if code >= http.StatusInternalServerError {
+		obs.LogWarning("HTTP client request returned server error", zap.String("method", method), zap.String("host", host), zap.Int("statusCode", code), zap.Duration("elapsedTime", elapsedTime))
+	}
+
+	return code, body, nil
+}
+
+func getProcessName(method, host string) string {
+	return "HTTP Client:" + method + ":" + host
+}
+
+// methodAttributes returns the request method attributes of the HTTP client semantic
+// conventions, mapping unknown methods to _OTHER.
+func methodAttributes(method string) []attribute.KeyValue {
+	switch method {
+	case http.MethodConnect, http.MethodDelete, http.MethodGet, http.MethodHead, http.MethodOptions,
+		http.MethodPatch, http.MethodPost, http.MethodPut, http.MethodTrace:
+		return []attribute.KeyValue{semconv.HTTPRequestMethodKey.String(method)}
+	default:
+		return []attribute.KeyValue{semconv.HTTPRequestMethodOther, semconv.HTTPRequestMethodOriginal(method)}
+	}
+}
+
+// setResponseStatus records the response status code on the client span and marks
+// the span as failed on server errors.
+func setResponseStatus(obs *observer.Observer, statusCode int) {
+	obs.SetAttributes(semconv.HTTPResponseStatusCode(statusCode))
+	if statusCode >= http.StatusInternalServerError {
+		obs.SetStatus(codes.Error, http.StatusText(statusCode))
+	}
+}
+
+func recordMetrics(method, host, statusCode string, elapsedTime time.Duration) {
+	collector := httpclientcollector.GetHttpClientCollector()
+	if collector == nil {
+		return
+	}
+
+	collector.IncRequestCount(method, host, statusCode)
+	collector.ObserveResponseTime(method, host, statusCode, elapsedTime)
+}
@@ -0,0 +1,106 @@
+package http_client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/todesdev/go-obs/internal/observer"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	observer.SetTracingEnabled(true)
+	t.Cleanup(func() {
+		observer.SetTracingEnabled(false)
+		otel.SetTracerProvider(previous)
+	})
+
+	return recorder
+}
+
+func TestClientSpanStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fail":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	clients := map[string]func(method, path string){
+		"Transport": func(method, path string) {
+			req, err := http.NewRequest(method, server.URL+path, nil)
+			if err != nil {
+				t.Fatalf("new request: %v", err)
+			}
+			resp, err := NewClient().Do(req)
+			if err != nil {
+				t.Fatalf("do: %v", err)
+			}
+			resp.Body.Close()
+		},
+		"AgentBytes": func(method, path string) {
+			a := fiber.AcquireAgent()
+			a.Request().Header.SetMethod(method)
+			a.Request().SetRequestURI(server.URL + path)
+			if _, _, errs := AgentBytes(context.Background(), a); len(errs) > 0 {
+				t.Fatalf("bytes: %v", errs)
+			}
+		},
+	}
+
+	tests := []struct {
+		method     string
+		path       string
+		statusCode int
+		status     codes.Code
+	}{
+		{method: http.MethodGet, path: "/ok", statusCode: http.StatusOK, status: codes.Unset},
+		{method: http.MethodPost, path: "/missing", statusCode: http.StatusNotFound, status: codes.Unset},
+		{method: http.MethodPut, path: "/fail", statusCode: http.StatusServiceUnavailable, status: codes.Error},
+	}
+
+	for name, do := range clients {
+		for _, tt := range tests {
+			t.Run(name+" "+tt.method+" "+tt.path, func(t *testing.T) {
+				recorder := recordSpans(t)
+
+				do(tt.method, tt.path)
+
+				spans := recorder.Ended()
+				if len(spans) != 1 {
+					t.Fatalf("ended spans = %d, want 1", len(spans))
+				}
+				span := spans[0]
+
+				if got := span.Status().Code; got != tt.status {
+					t.Errorf("span status = %v, want %v", got, tt.status)
+				}
+				attrs := attribute.NewSet(span.Attributes()...)
+				if got, _ := attrs.Value(semconv.HTTPResponseStatusCodeKey); got.AsInt64() != int64(tt.statusCode) {
+					t.Errorf("%s = %v, want %d", semconv.HTTPResponseStatusCodeKey, got.Emit(), tt.statusCode)
+				}
+				if got, _ := attrs.Value(semconv.HTTPRequestMethodKey); got.AsString() != tt.method {
+					t.Errorf("%s = %q, want %q", semconv.HTTPRequestMethodKey, got.AsString(), tt.method)
+				}
+			})
+		}
+	}
+}
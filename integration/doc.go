@@ -0,0 +1,4 @@
+// Package integration holds the tests that run against an embedded NATS server. It is
+// a separate module so that the server is not a dependency of go-obs; run the tests
+// from this directory with go test ./...
+package integration
@@ -0,0 +1,230 @@
+package integration
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/prometheus/client_golang/prometheus"
+	natscollector "github.com/todesdev/go-obs/internal/metrics/nats_collector"
+	"github.com/todesdev/go-obs/nats_wrappers"
+)
+
+// startJetStream runs an embedded JetStream server with a stream capturing "test.>".
+func startJetStream(t *testing.T) nats.JetStreamContext {
+	t.Helper()
+
+	srv, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, JetStream: true, StoreDir: t.TempDir(), NoLog: true, NoSigs: true})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	go srv.Start()
+	t.Cleanup(srv.Shutdown)
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("server not ready")
+	}
+
+	nc, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(nc.Close)
+
+	js, err := nc.JetStream()
+	if err != nil {
+		t.Fatalf("jetstream: %v", err)
+	}
+	if _, err := js.AddStream(&nats.StreamConfig{Name: "TEST", Subjects: []string{"test.>"}}); err != nil {
+		t.Fatalf("add stream: %v", err)
+	}
+
+	return js
+}
+
+func publish(t *testing.T, js nats.JetStreamContext, subject string, data string) {
+	t.Helper()
+
+	if _, err := js.Publish(subject, []byte(data)); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+}
+
+// waitFor polls cond until it holds or the timeout expires.
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before timeout")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSubscriberCancelWaitsForHandlers(t *testing.T) {
+	js := startJetStream(t)
+
+	for i := 0; i < 6; i++ {
+		publish(t, js, "test.slow", strconv.Itoa(i))
+	}
+
+	var started, finished atomic.Int32
+	release := make(chan struct{})
+	handler := func(msg *nats.Msg, _ ...context.Context) error {
+		started.Add(1)
+		<-release
+		time.Sleep(50 * time.Millisecond)
+		finished.Add(1)
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sub, err := nats_wrappers.SubscribeWithObservabilityConfig(ctx, js, "test.slow", "slow", handler, nats_wrappers.SubscriberConfig{Workers: 2, MaxInFlight: 3}, nats.ManualAck(), nats.AckWait(10*time.Second))
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	waitFor(t, 5*time.Second, func() bool { return started.Load() == 2 })
+	cancel()
+	close(release)
+
+	select {
+	case <-sub.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscriber did not stop")
+	}
+
+	// Both running handlers complete, as does a message queued before ctx was
+	// cancelled, but no more than MaxInFlight messages are received.
+	if got := finished.Load(); got < 2 || got > 3 {
+		t.Errorf("finished handlers = %d, want 2 or 3", got)
+	}
+	if got := started.Load(); got != finished.Load() {
+		t.Errorf("started handlers = %d, finished = %d", got, finished.Load())
+	}
+	// The subscription is kept, so the queue's durable consumer is not deleted.
+	if !sub.IsValid() {
+		t.Error("subscription unsubscribed after Done")
+	}
+	if _, err := js.ConsumerInfo("TEST", "slow"); err != nil {
+		t.Errorf("consumer info after Done: %v", err)
+	}
+	if err := sub.Wait(context.Background()); err != nil {
+		t.Errorf("Wait after Done = %v", err)
+	}
+}
+
+func TestSubscriberMaxInFlightAndOrdering(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	if err := natscollector.Setup(registry, "test"); err != nil {
+		t.Fatalf("setup collector: %v", err)
+	}
+	t.Cleanup(func() { natscollector.Teardown(registry) })
+
+	js := startJetStream(t)
+
+	subjects := []string{"test.a", "test.b", "test.c"}
+	const perSubject = 10
+	for i := 0; i < perSubject; i++ {
+		for _, subject := range subjects {
+			publish(t, js, subject, strconv.Itoa(i))
+		}
+	}
+
+	const inFlightLimit = 4
+	var (
+		mu           sync.Mutex
+		seen         = map[string][]int{}
+		running      int
+		peakRunning  int
+		peakInFlight int
+		handled      atomic.Int32
+	)
+	handler := func(msg *nats.Msg, _ ...context.Context) error {
+		inFlight := gaugeSum(t, registry, "test_nats_in_flight_messages")
+		seq, _ := strconv.Atoi(string(msg.Data))
+
+		mu.Lock()
+		running++
+		peakRunning = max(peakRunning, running)
+		peakInFlight = max(peakInFlight, inFlight)
+		seen[msg.Subject] = append(seen[msg.Subject], seq)
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+		handled.Add(1)
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := nats_wrappers.SubscriberConfig{Workers: 3, MaxInFlight: inFlightLimit, OrderingKey: nats_wrappers.SubjectOrderingKey}
+	sub, err := nats_wrappers.SubscribeWithObservabilityConfig(ctx, js, "test.>", "ordered", handler, cfg, nats.ManualAck())
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	waitFor(t, 10*time.Second, func() bool { return handled.Load() == int32(len(subjects)*perSubject) })
+	cancel()
+	<-sub.Done()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if peakInFlight > inFlightLimit {
+		t.Errorf("in-flight messages peaked at %d, want at most %d", peakInFlight, inFlightLimit)
+	}
+	if peakRunning > cfg.Workers {
+		t.Errorf("concurrent handlers peaked at %d, want at most %d", peakRunning, cfg.Workers)
+	}
+	for _, subject := range subjects {
+		got := seen[subject]
+		if len(got) != perSubject {
+			t.Errorf("%s: handled %d messages, want %d", subject, len(got), perSubject)
+			continue
+		}
+		for i, seq := range got {
+			if seq != i {
+				t.Errorf("%s: handled out of order: %v", subject, got)
+				break
+			}
+		}
+	}
+	if got := gaugeSum(t, registry, "test_nats_in_flight_messages"); got != 0 {
+		t.Errorf("in-flight messages after Done = %d, want 0", got)
+	}
+}
+
+func gaugeSum(t *testing.T, registry *prometheus.Registry, name string) int {
+	t.Helper()
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+
+	var sum float64
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			sum += m.GetGauge().GetValue()
+		}
+	}
+
+	return int(sum)
+}
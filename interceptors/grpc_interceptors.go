@@ -3,35 +3,241 @@ package interceptors
 import (
 	"context"
 	"github.com/todesdev/go-obs/internal/metrics/grpc_collector"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
 func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
-	return func (ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		collector := grpc_collector.GetGrpcCollector()
+		if collector == nil {
+			return handler(ctx, req)
+		}
+
 		start := time.Now()
+		service, method := splitMethodName(info.FullMethod)
+		annotatePeer(ctx)
+
+		collector.IncRequestsInFlight(service, method)
+		defer collector.DecRequestsInFlight(service, method)
+
+		if size, ok := messageSize(req); ok {
+			collector.ObserveRequestMessageSize(service, method, size)
+		}
+
 		h, err := handler(ctx, req)
-		collector := grpc_collector.GetGrpcCollector()
-		method := info.FullMethod
-		collector.IncRequestsInFlight(method)
-		defer collector.DecRequestsInFlight(method)
-		collector.IncRequestCount(method, status.Code(err).String())
-		collector.ObserveResponseTime(method, status.Code(err).String(), time.Since(start))
+
+		if size, ok := messageSize(h); ok && err == nil {
+			collector.ObserveResponseMessageSize(service, method, size)
+		}
+
+		code := status.Code(err).String()
+		collector.IncRequestCount(service, method, code)
+		collector.ObserveResponseTime(service, method, code, time.Since(start))
 		return h, err
 	}
 }
 
 func StreamServerInterceptor() grpc.StreamServerInterceptor {
-	return func (srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		collector := grpc_collector.GetGrpcCollector()
+		if collector == nil {
+			return handler(srv, stream)
+		}
+
 		start := time.Now()
-		err := handler(srv, stream)
+		service, method := splitMethodName(info.FullMethod)
+		annotatePeer(stream.Context())
+
+		collector.IncRequestsInFlight(service, method)
+		defer collector.DecRequestsInFlight(service, method)
+
+		err := handler(srv, &monitoredServerStream{ServerStream: stream, collector: collector, service: service, method: method})
+
+		code := status.Code(err).String()
+		collector.IncRequestCount(service, method, code)
+		collector.ObserveResponseTime(service, method, code, time.Since(start))
+		return err
+	}
+}
+
+// monitoredServerStream records the size of every message received and sent on a stream.
+type monitoredServerStream struct {
+	grpc.ServerStream
+	collector *grpc_collector.GrpcCollector
+	service   string
+	method    string
+}
+
+func (s *monitoredServerStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if size, ok := messageSize(m); ok && err == nil {
+		s.collector.ObserveResponseMessageSize(s.service, s.method, size)
+	}
+	return err
+}
+
+func (s *monitoredServerStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if size, ok := messageSize(m); ok && err == nil {
+		s.collector.ObserveRequestMessageSize(s.service, s.method, size)
+	}
+	return err
+}
+
+// splitMethodName splits "/package.Service/Method" into its service and method parts.
+func splitMethodName(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "unknown", fullMethod
+}
+
+func messageSize(m interface{}) (int, bool) {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return 0, false
+	}
+	return proto.Size(msg), true
+}
+
+// annotatePeer adds the caller's address to the active server span. The address is kept
+// off the metric labels to bound their cardinality.
+func annotatePeer(ctx context.Context) {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return
+	}
+
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+
+	host, port, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		span.SetAttributes(semconv.ClientAddress(p.Addr.String()))
+		return
+	}
+
+	span.SetAttributes(semconv.ClientAddress(host))
+	if portNumber, err := strconv.Atoi(port); err == nil {
+		span.SetAttributes(semconv.ClientPort(portNumber))
+	}
+}
+
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx = outgoingRequestID(ctx)
 		collector := grpc_collector.GetGrpcCollector()
-		method := info.FullMethod
-		collector.IncRequestsInFlight(method)
-		defer collector.DecRequestsInFlight(method)
-		collector.IncRequestCount(method, status.Code(err).String())
-		collector.ObserveResponseTime(method, status.Code(err).String(), time.Since(start))
+		if collector == nil {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		start := time.Now()
+		service, methodName := splitMethodName(method)
+		collector.IncClientRequestsInFlight(service, methodName)
+		defer collector.DecClientRequestsInFlight(service, methodName)
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		code := status.Code(err).String()
+		collector.IncClientRequestCount(service, methodName, code)
+		collector.ObserveClientResponseTime(service, methodName, code, time.Since(start))
 		return err
 	}
-}
\ No newline at end of file
+}
+
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx = outgoingRequestID(ctx)
+		collector := grpc_collector.GetGrpcCollector()
+		if collector == nil {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+
+		start := time.Now()
+		service, methodName := splitMethodName(method)
+		collector.IncClientRequestsInFlight(service, methodName)
+
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			recordClientStreamEnd(collector, service, methodName, start, err)
+			return nil, err
+		}
+
+		s := &monitoredClientStream{ClientStream: stream, collector: collector, service: service, method: methodName, start: start, serverStreams: desc.ServerStreams, done: make(chan struct{})}
+		go s.finishOnCancel(ctx)
+		return s, nil
+	}
+}
+
+// monitoredClientStream records the client metrics once the stream terminates, which is
+// when RecvMsg returns an error (io.EOF for a clean end), SendMsg fails, the single
+// response of a client-streaming call has been received, or the caller's context is
+// done before any of these, e.g. when the stream is cancelled without being drained.
+type monitoredClientStream struct {
+	grpc.ClientStream
+	collector     *grpc_collector.GrpcCollector
+	service       string
+	method        string
+	start         time.Time
+	serverStreams bool
+	once          sync.Once
+	done          chan struct{}
+}
+
+func (s *monitoredClientStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	if err != nil && err != io.EOF {
+		s.finish(err)
+	}
+	return err
+}
+
+func (s *monitoredClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	switch {
+	case err == io.EOF:
+		s.finish(nil)
+	case err != nil:
+		s.finish(err)
+	case !s.serverStreams:
+		s.finish(nil)
+	}
+	return err
+}
+
+func (s *monitoredClientStream) finish(err error) {
+	s.once.Do(func() {
+		close(s.done)
+		recordClientStreamEnd(s.collector, s.service, s.method, s.start, err)
+	})
+}
+
+// finishOnCancel watches the caller's context rather than the stream's, which gRPC
+// also cancels when the stream ends normally.
+func (s *monitoredClientStream) finishOnCancel(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+		s.finish(status.FromContextError(ctx.Err()).Err())
+	case <-s.done:
+	}
+}
+
+func recordClientStreamEnd(collector *grpc_collector.GrpcCollector, service, method string, start time.Time, err error) {
+	code := status.Code(err).String()
+	collector.DecClientRequestsInFlight(service, method)
+	collector.IncClientRequestCount(service, method, code)
+	collector.ObserveClientResponseTime(service, method, code, time.Since(start))
+}
@@ -0,0 +1,85 @@
+package interceptors
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/todesdev/go-obs/internal/observer"
+	"github.com/todesdev/go-obs/internal/recovery"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// ObserverUnaryServerInterceptor attaches an Observer for the call's server span to the
+// handler context, where handlers retrieve it with observer.FromContext, and logs the
+// start and completion of every call. Errors are recorded on the span. The request ID
+// received in the call metadata, or a generated one, is added to the context and the
+// log lines and echoed in the response header.
+func ObserverUnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		ctx, header := incomingRequestID(ctx)
+		_ = grpc.SetHeader(ctx, header)
+		obs := observer.ActiveSpanObserver(ctx, getProcessName(info.FullMethod))
+		defer obs.End()
+
+		obs.LogInfo("Request received", zap.String("method", info.FullMethod))
+
+		h, err := handler(observer.NewContext(obs.Ctx(), obs), req)
+
+		logCompletion(obs, info.FullMethod, start, err)
+		return h, err
+	}
+}
+
+// ObserverStreamServerInterceptor is the streaming counterpart of ObserverUnaryServerInterceptor.
+func ObserverStreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx, header := incomingRequestID(stream.Context())
+		_ = stream.SetHeader(header)
+		obs := observer.ActiveSpanObserver(ctx, getProcessName(info.FullMethod))
+		defer obs.End()
+
+		obs.LogInfo("Stream opened", zap.String("method", info.FullMethod))
+
+		err := handler(srv, &observedServerStream{ServerStream: stream, ctx: observer.NewContext(obs.Ctx(), obs)})
+
+		logCompletion(obs, info.FullMethod, start, err)
+		return err
+	}
+}
+
+// observedServerStream overrides the stream context so handlers see the attached Observer.
+type observedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *observedServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func getProcessName(fullMethod string) string {
+	return "GRPC:" + fullMethod
+}
+
+func logCompletion(obs *observer.Observer, fullMethod string, start time.Time, err error) {
+	elapsedTime := time.Since(start)
+	code := status.Code(err)
+
+	// A recovered panic has already been recorded with its stack.
+	var panicErr *recovery.PanicError
+	if errors.As(err, &panicErr) {
+		return
+	}
+
+	if err != nil {
+		obs.RecordErrorWithLogging("Request error", err, zap.String("method", fullMethod), zap.String("code", code.String()), zap.Duration("elapsedTime", elapsedTime))
+		return
+	}
+
+	obs.RecordInfoWithLogging("Request completed", zap.String("method", fullMethod), zap.String("code", code.String()), zap.Duration("elapsedTime", elapsedTime))
+}
@@ -0,0 +1,73 @@
+package interceptors
+
+import (
+	"context"
+
+	paniccollector "github.com/todesdev/go-obs/internal/metrics/panic_collector"
+	"github.com/todesdev/go-obs/internal/observer"
+	"github.com/todesdev/go-obs/internal/recovery"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RecoveryUnaryServerInterceptor converts a panic in the handler into a codes.Internal
+// error, recording it with its stack on the call's span. It should be the innermost
+// interceptor so the metrics and logging interceptors see the resulting status.
+func RecoveryUnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = handlePanic(ctx, info.FullMethod, r)
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// RecoveryStreamServerInterceptor is the streaming counterpart of RecoveryUnaryServerInterceptor.
+func RecoveryStreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = handlePanic(stream.Context(), info.FullMethod, r)
+			}
+		}()
+
+		return handler(srv, stream)
+	}
+}
+
+func handlePanic(ctx context.Context, fullMethod string, recovered interface{}) error {
+	panicErr := recovery.Recovered(paniccollector.ComponentGRPC, recovered)
+
+	obs := observer.FromContext(ctx)
+	if obs == nil {
+		obs = observer.ActiveSpanObserver(ctx, getProcessName(fullMethod))
+	}
+	obs.RecordPanicWithLogging("Request panicked", panicErr, panicErr.Stack, zap.String("method", fullMethod))
+
+	return &recoveredError{status: status.New(codes.Internal, "internal error"), panicErr: panicErr}
+}
+
+// recoveredError is the codes.Internal error returned for a recovered panic. It
+// unwraps to the *recovery.PanicError, so the outer interceptors can tell it has
+// already been recorded, while clients only get the generic status.
+type recoveredError struct {
+	status   *status.Status
+	panicErr *recovery.PanicError
+}
+
+func (e *recoveredError) Error() string {
+	return e.status.Err().Error()
+}
+
+func (e *recoveredError) GRPCStatus() *status.Status {
+	return e.status
+}
+
+func (e *recoveredError) Unwrap() error {
+	return e.panicErr
+}
@@ -0,0 +1,37 @@
+package interceptors
+
+import (
+	"context"
+
+	"github.com/todesdev/go-obs/internal/requestid"
+	"google.golang.org/grpc/metadata"
+)
+
+// incomingRequestID stores the request ID received in the call metadata in ctx,
+// generating one if the caller sent none, and returns the header metadata echoing it.
+func incomingRequestID(ctx context.Context) (context.Context, metadata.MD) {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestid.MetadataKey()); len(values) > 0 {
+			id = values[0]
+		}
+	}
+	id = requestid.Ensure(id)
+
+	return requestid.NewContext(ctx, id), metadata.Pairs(requestid.MetadataKey(), id)
+}
+
+// outgoingRequestID adds the request ID stored in ctx to the outgoing call metadata,
+// unless the caller already set one.
+func outgoingRequestID(ctx context.Context) context.Context {
+	id := requestid.FromContext(ctx)
+	if id == "" {
+		return ctx
+	}
+
+	if md, ok := metadata.FromOutgoingContext(ctx); ok && len(md.Get(requestid.MetadataKey())) > 0 {
+		return ctx
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, requestid.MetadataKey(), id)
+}
@@ -26,6 +26,13 @@ func TracedLoggerWithProcess(span trace.Span, processName string) *Logger {
 	}
 }
 
+// With returns a Logger that adds fields to every entry.
+func (l *Logger) With(fields ...zap.Field) *Logger {
+	return &Logger{
+		logger: l.logger.With(fields...),
+	}
+}
+
 func (l *Logger) Info(msg string, fields ...zap.Field) {
 	l.logger.Info(msg, fields...)
 }
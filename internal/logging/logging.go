@@ -1,14 +1,16 @@
 package logging
 
 import (
+	"errors"
+	"syscall"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
 var logger *zap.Logger
 
-func Setup(region, serviceName, serviceVersion, logLevel string) {
-
+func Setup(region, serviceName, serviceVersion, logLevel string) error {
 	cfg := zap.Config{
 		Level:             zap.NewAtomicLevelAt(getLogLevel(logLevel)),
 		Development:       false,
@@ -32,21 +34,41 @@ func Setup(region, serviceName, serviceVersion, logLevel string) {
 		OutputPaths:      []string{"stdout"},
 		ErrorOutputPaths: []string{"stderr"},
 	}
-	var err error
 
-	logger, err = cfg.Build()
+	built, err := cfg.Build()
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	logger = logger.With(
+	logger = built.With(
 		zap.String("region", region),
 		zap.String("service", serviceName),
 		zap.String("version", serviceVersion),
 	)
+
+	return nil
+}
+
+// Sync flushes any buffered log entries. Errors caused by syncing a terminal or pipe
+// (stdout/stderr do not support fsync) are ignored.
+func Sync() error {
+	if logger == nil {
+		return nil
+	}
+
+	err := logger.Sync()
+	if errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOTTY) {
+		return nil
+	}
+
+	return err
 }
 
 func getLogger() *zap.Logger {
+	if logger == nil {
+		return zap.NewNop()
+	}
+
 	return logger
 }
 
@@ -0,0 +1,113 @@
+package exportercollector
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/todesdev/go-obs/internal/logging"
+)
+
+const (
+	ExporterSubsystem = "tracing_exporter"
+
+	ExporterUp                 = "up"
+	ExporterExportedSpansTotal = "exported_spans_total"
+
+	ExporterUpHelp                 = "Whether the last span export to the collector succeeded (1) or failed (0); absent before the first export."
+	ExporterExportedSpansTotalHelp = "Total number of spans handed to the tracing exporter."
+
+	ExporterResultLabel = "result"
+
+	ExporterResultSuccess = "success"
+	ExporterResultFailure = "failure"
+)
+
+var exporterCollector *ExporterCollector
+
+type ExporterCollector struct {
+	mu            sync.Mutex
+	up            *prometheus.GaugeVec
+	exportedSpans *prometheus.CounterVec
+}
+
+func newExporterCollector(serviceName string) *ExporterCollector {
+	// A vector without labels has no series until its first Set, so the up gauge is
+	// absent until the first export result instead of reading 0 during startup.
+	up := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: prometheus.BuildFQName(serviceName, ExporterSubsystem, ExporterUp),
+			Help: ExporterUpHelp,
+		},
+		nil,
+	)
+
+	exportedSpans := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: prometheus.BuildFQName(serviceName, ExporterSubsystem, ExporterExportedSpansTotal),
+			Help: ExporterExportedSpansTotalHelp,
+		},
+		[]string{ExporterResultLabel},
+	)
+
+	exporterCollector = &ExporterCollector{
+		up:            up,
+		exportedSpans: exportedSpans,
+	}
+
+	return exporterCollector
+}
+
+func (collector *ExporterCollector) Register(registry *prometheus.Registry) error {
+	for _, c := range []prometheus.Collector{collector.up, collector.exportedSpans} {
+		if err := registry.Register(c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (collector *ExporterCollector) Unregister(registry *prometheus.Registry) {
+	registry.Unregister(collector.up)
+	registry.Unregister(collector.exportedSpans)
+}
+
+func Setup(registry *prometheus.Registry, serviceName string) error {
+	logger := logging.LoggerWithProcess("ExporterCollectorSetup")
+	logger.Info("Setting up tracing exporter metrics...")
+	if err := newExporterCollector(serviceName).Register(registry); err != nil {
+		return fmt.Errorf("register tracing exporter collector: %w", err)
+	}
+
+	logger.Info("Tracing exporter metrics setup complete")
+	return nil
+}
+
+func Teardown(registry *prometheus.Registry) {
+	if exporterCollector == nil {
+		return
+	}
+
+	exporterCollector.Unregister(registry)
+}
+
+func GetExporterCollector() *ExporterCollector {
+	return exporterCollector
+}
+
+func (collector *ExporterCollector) SetUp(up bool) {
+	collector.mu.Lock()
+	if up {
+		collector.up.WithLabelValues().Set(1)
+	} else {
+		collector.up.WithLabelValues().Set(0)
+	}
+	collector.mu.Unlock()
+}
+
+func (collector *ExporterCollector) AddExportedSpans(result string, count int) {
+	collector.mu.Lock()
+	collector.exportedSpans.WithLabelValues(result).Add(float64(count))
+	collector.mu.Unlock()
+}
@@ -1,6 +1,7 @@
 package grpc_collector
 
 import (
+	"fmt"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/todesdev/go-obs/internal/logging"
 	"sync"
@@ -8,29 +9,47 @@ import (
 )
 
 const (
-	GrpcSubsystem = "grpc"
+	GrpcSubsystem       = "grpc"
+	GrpcClientSubsystem = "grpc_client"
 
-	GrpcRequestsTotal           = "requests_total"
-	GrpcRequestDurationSeconds  = "request_duration_seconds"
-	GrpcRequestsInProgressTotal = "requests_in_progress_total"
+	GrpcRequestsTotal            = "requests_total"
+	GrpcRequestDurationSeconds   = "request_duration_seconds"
+	GrpcRequestsInProgressTotal  = "requests_in_progress_total"
+	GrpcRequestMessageSizeBytes  = "request_message_size_bytes"
+	GrpcResponseMessageSizeBytes = "response_message_size_bytes"
 
-	GrpcRequestsHelp                = "Total number of gRPC requests."
+	GrpcRequestsHelp               = "Total number of gRPC requests."
 	GrpcRequestDurationSecondsHelp = "Duration of gRPC requests."
-	GrpcRequestsInProgressHelp      = "Number of gRPC requests in progress."
+	GrpcRequestsInProgressHelp     = "Number of gRPC requests in progress."
+	GrpcRequestMessageSizeHelp     = "Size of received gRPC request messages."
+	GrpcResponseMessageSizeHelp    = "Size of sent gRPC response messages."
+
+	GrpcClientRequestsHelp               = "Total number of outbound gRPC requests."
+	GrpcClientRequestDurationSecondsHelp = "Duration of outbound gRPC requests."
+	GrpcClientRequestsInProgressHelp     = "Number of outbound gRPC requests in progress."
 
 	GrpcStatusCodeLabel = "status_code"
+	GrpcServiceLabel    = "service"
 	GrpcMethodLabel     = "method"
 )
 
 var (
 	grpcCollector *GrpcCollector
+
+	// messageSizeBuckets spans 64B to 16MiB, covering the default 4MiB gRPC message limit.
+	messageSizeBuckets = prometheus.ExponentialBuckets(64, 4, 10)
 )
 
 type GrpcCollector struct {
-	mu               sync.Mutex
-	requestCount     *prometheus.CounterVec
-	responseTime     *prometheus.HistogramVec
-	requestsInFlight *prometheus.GaugeVec
+	mu                     sync.Mutex
+	requestCount           *prometheus.CounterVec
+	responseTime           *prometheus.HistogramVec
+	requestsInFlight       *prometheus.GaugeVec
+	requestMessageSize     *prometheus.HistogramVec
+	responseMessageSize    *prometheus.HistogramVec
+	clientRequestCount     *prometheus.CounterVec
+	clientResponseTime     *prometheus.HistogramVec
+	clientRequestsInFlight *prometheus.GaugeVec
 }
 
 func newGrpcCollector(serviceName string) *GrpcCollector {
@@ -39,7 +58,7 @@ func newGrpcCollector(serviceName string) *GrpcCollector {
 			Name: prometheus.BuildFQName(serviceName, GrpcSubsystem, GrpcRequestsTotal),
 			Help: GrpcRequestsHelp,
 		},
-		[]string{GrpcMethodLabel, GrpcStatusCodeLabel},
+		[]string{GrpcServiceLabel, GrpcMethodLabel, GrpcStatusCodeLabel},
 	)
 
 	responseTime := prometheus.NewHistogramVec(
@@ -48,7 +67,7 @@ func newGrpcCollector(serviceName string) *GrpcCollector {
 			Help:    GrpcRequestDurationSecondsHelp,
 			Buckets: prometheus.DefBuckets,
 		},
-		[]string{GrpcMethodLabel, GrpcStatusCodeLabel},
+		[]string{GrpcServiceLabel, GrpcMethodLabel, GrpcStatusCodeLabel},
 	)
 
 	requestsInFlight := prometheus.NewGaugeVec(
@@ -56,56 +75,170 @@ func newGrpcCollector(serviceName string) *GrpcCollector {
 			Name: prometheus.BuildFQName(serviceName, GrpcSubsystem, GrpcRequestsInProgressTotal),
 			Help: GrpcRequestsInProgressHelp,
 		},
-		[]string{GrpcMethodLabel},
+		[]string{GrpcServiceLabel, GrpcMethodLabel},
+	)
+
+	requestMessageSize := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    prometheus.BuildFQName(serviceName, GrpcSubsystem, GrpcRequestMessageSizeBytes),
+			Help:    GrpcRequestMessageSizeHelp,
+			Buckets: messageSizeBuckets,
+		},
+		[]string{GrpcServiceLabel, GrpcMethodLabel},
+	)
+
+	responseMessageSize := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    prometheus.BuildFQName(serviceName, GrpcSubsystem, GrpcResponseMessageSizeBytes),
+			Help:    GrpcResponseMessageSizeHelp,
+			Buckets: messageSizeBuckets,
+		},
+		[]string{GrpcServiceLabel, GrpcMethodLabel},
+	)
+
+	clientRequestCount := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: prometheus.BuildFQName(serviceName, GrpcClientSubsystem, GrpcRequestsTotal),
+			Help: GrpcClientRequestsHelp,
+		},
+		[]string{GrpcServiceLabel, GrpcMethodLabel, GrpcStatusCodeLabel},
+	)
+
+	clientResponseTime := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    prometheus.BuildFQName(serviceName, GrpcClientSubsystem, GrpcRequestDurationSeconds),
+			Help:    GrpcClientRequestDurationSecondsHelp,
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{GrpcServiceLabel, GrpcMethodLabel, GrpcStatusCodeLabel},
+	)
+
+	clientRequestsInFlight := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: prometheus.BuildFQName(serviceName, GrpcClientSubsystem, GrpcRequestsInProgressTotal),
+			Help: GrpcClientRequestsInProgressHelp,
+		},
+		[]string{GrpcServiceLabel, GrpcMethodLabel},
 	)
 
 	grpcCollector = &GrpcCollector{
-		requestCount:     requestCount,
-		responseTime:     responseTime,
-		requestsInFlight: requestsInFlight,
+		requestCount:           requestCount,
+		responseTime:           responseTime,
+		requestsInFlight:       requestsInFlight,
+		requestMessageSize:     requestMessageSize,
+		responseMessageSize:    responseMessageSize,
+		clientRequestCount:     clientRequestCount,
+		clientResponseTime:     clientResponseTime,
+		clientRequestsInFlight: clientRequestsInFlight,
 	}
 
 	return grpcCollector
 }
 
-func (collector *GrpcCollector) Register(registry *prometheus.Registry) {
-	registry.MustRegister(collector.requestCount)
-	registry.MustRegister(collector.responseTime)
-	registry.MustRegister(collector.requestsInFlight)
+func (collector *GrpcCollector) Register(registry *prometheus.Registry) error {
+	for _, c := range []prometheus.Collector{
+		collector.requestCount, collector.responseTime, collector.requestsInFlight,
+		collector.requestMessageSize, collector.responseMessageSize,
+		collector.clientRequestCount, collector.clientResponseTime, collector.clientRequestsInFlight,
+	} {
+		if err := registry.Register(c); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
-func Setup(registry *prometheus.Registry, serviceName string) {
+func (collector *GrpcCollector) Unregister(registry *prometheus.Registry) {
+	registry.Unregister(collector.requestCount)
+	registry.Unregister(collector.responseTime)
+	registry.Unregister(collector.requestsInFlight)
+	registry.Unregister(collector.requestMessageSize)
+	registry.Unregister(collector.responseMessageSize)
+	registry.Unregister(collector.clientRequestCount)
+	registry.Unregister(collector.clientResponseTime)
+	registry.Unregister(collector.clientRequestsInFlight)
+}
+
+func Setup(registry *prometheus.Registry, serviceName string) error {
 	logger := logging.LoggerWithProcess("GrpcCollectorSetup")
 	logger.Info("Setting up gRPC metrics...")
-	newGrpcCollector(serviceName).Register(registry)
+	if err := newGrpcCollector(serviceName).Register(registry); err != nil {
+		return fmt.Errorf("register gRPC collector: %w", err)
+	}
 
 	logger.Info("gRPC metrics setup complete")
+	return nil
+}
+
+func Teardown(registry *prometheus.Registry) {
+	if grpcCollector == nil {
+		return
+	}
+
+	grpcCollector.Unregister(registry)
 }
 
 func GetGrpcCollector() *GrpcCollector {
 	return grpcCollector
 }
 
-func (collector *GrpcCollector) IncRequestCount(method, statusCode string) {
+func (collector *GrpcCollector) IncRequestCount(service, method, statusCode string) {
+	collector.mu.Lock()
+	collector.requestCount.WithLabelValues(service, method, statusCode).Inc()
+	collector.mu.Unlock()
+}
+
+func (collector *GrpcCollector) ObserveResponseTime(service, method, statusCode string, duration time.Duration) {
+	collector.mu.Lock()
+	collector.responseTime.WithLabelValues(service, method, statusCode).Observe(float64(duration) / float64(time.Second))
+	collector.mu.Unlock()
+}
+
+func (collector *GrpcCollector) IncRequestsInFlight(service, method string) {
+	collector.mu.Lock()
+	collector.requestsInFlight.WithLabelValues(service, method).Inc()
+	collector.mu.Unlock()
+}
+
+func (collector *GrpcCollector) DecRequestsInFlight(service, method string) {
+	collector.mu.Lock()
+	collector.requestsInFlight.WithLabelValues(service, method).Dec()
+	collector.mu.Unlock()
+}
+
+func (collector *GrpcCollector) ObserveRequestMessageSize(service, method string, size int) {
+	collector.mu.Lock()
+	collector.requestMessageSize.WithLabelValues(service, method).Observe(float64(size))
+	collector.mu.Unlock()
+}
+
+func (collector *GrpcCollector) ObserveResponseMessageSize(service, method string, size int) {
 	collector.mu.Lock()
-	collector.requestCount.WithLabelValues(method, statusCode).Inc()
+	collector.responseMessageSize.WithLabelValues(service, method).Observe(float64(size))
 	collector.mu.Unlock()
 }
 
-func (collector *GrpcCollector) ObserveResponseTime(method, statusCode string, duration time.Duration) {
+func (collector *GrpcCollector) IncClientRequestCount(service, method, statusCode string) {
 	collector.mu.Lock()
-	collector.responseTime.WithLabelValues(method, statusCode).Observe(float64(duration) / float64(time.Second))
+	collector.clientRequestCount.WithLabelValues(service, method, statusCode).Inc()
 	collector.mu.Unlock()
 }
 
-func (collector *GrpcCollector) IncRequestsInFlight(method string) {
+func (collector *GrpcCollector) ObserveClientResponseTime(service, method, statusCode string, duration time.Duration) {
 	collector.mu.Lock()
-	collector.requestsInFlight.WithLabelValues(method).Inc()
+	collector.clientResponseTime.WithLabelValues(service, method, statusCode).Observe(float64(duration) / float64(time.Second))
 	collector.mu.Unlock()
 }
 
-func (collector *GrpcCollector) DecRequestsInFlight(method string) {
+func (collector *GrpcCollector) IncClientRequestsInFlight(service, method string) {
 	collector.mu.Lock()
-	collector.requestsInFlight.WithLabelValues(method).Dec()
+	collector.clientRequestsInFlight.WithLabelValues(service, method).Inc()
 	collector.mu.Unlock()
-}
\ No newline at end of file
+}
+
+func (collector *GrpcCollector) DecClientRequestsInFlight(service, method string) {
+	collector.mu.Lock()
+	collector.clientRequestsInFlight.WithLabelValues(service, method).Dec()
+	collector.mu.Unlock()
+}
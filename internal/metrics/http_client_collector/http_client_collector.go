@@ -0,0 +1,111 @@
+package httpclientcollector
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/todesdev/go-obs/internal/logging"
+)
+
+const (
+	HttpClientSubsystem = "http_client"
+
+	HttpClientRequestsTotal          = "requests_total"
+	HttpClientRequestDurationSeconds = "request_duration_seconds"
+
+	HttpClientRequestsHelp               = "Total number of outbound HTTP requests."
+	HttpClientRequestDurationSecondsHelp = "Duration of outbound HTTP requests."
+
+	HttpClientMethodLabel     = "method"
+	HttpClientHostLabel       = "host"
+	HttpClientStatusCodeLabel = "status_code"
+
+	// HttpClientErrorStatus is the status_code label of requests that failed without a response.
+	HttpClientErrorStatus = "error"
+)
+
+var httpClientCollector *HttpClientCollector
+
+type HttpClientCollector struct {
+	mu           sync.Mutex
+	requestCount *prometheus.CounterVec
+	responseTime *prometheus.HistogramVec
+}
+
+func newHttpClientCollector(serviceName string) *HttpClientCollector {
+	requestCount := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: prometheus.BuildFQName(serviceName, HttpClientSubsystem, HttpClientRequestsTotal),
+			Help: HttpClientRequestsHelp,
+		},
+		[]string{HttpClientMethodLabel, HttpClientHostLabel, HttpClientStatusCodeLabel},
+	)
+
+	responseTime := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    prometheus.BuildFQName(serviceName, HttpClientSubsystem, HttpClientRequestDurationSeconds),
+			Help:    HttpClientRequestDurationSecondsHelp,
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{HttpClientMethodLabel, HttpClientHostLabel, HttpClientStatusCodeLabel},
+	)
+
+	httpClientCollector = &HttpClientCollector{
+		requestCount: requestCount,
+		responseTime: responseTime,
+	}
+
+	return httpClientCollector
+}
+
+func (collector *HttpClientCollector) Register(registry *prometheus.Registry) error {
+	for _, c := range []prometheus.Collector{collector.requestCount, collector.responseTime} {
+		if err := registry.Register(c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (collector *HttpClientCollector) Unregister(registry *prometheus.Registry) {
+	registry.Unregister(collector.requestCount)
+	registry.Unregister(collector.responseTime)
+}
+
+func Setup(registry *prometheus.Registry, serviceName string) error {
+	logger := logging.LoggerWithProcess("HttpClientCollectorSetup")
+	logger.Info("Setting up HTTP client metrics...")
+	if err := newHttpClientCollector(serviceName).Register(registry); err != nil {
+		return fmt.Errorf("register HTTP client collector: %w", err)
+	}
+
+	logger.Info("HTTP client metrics setup complete")
+	return nil
+}
+
+func Teardown(registry *prometheus.Registry) {
+	if httpClientCollector == nil {
+		return
+	}
+
+	httpClientCollector.Unregister(registry)
+}
+
+func GetHttpClientCollector() *HttpClientCollector {
+	return httpClientCollector
+}
+
+func (collector *HttpClientCollector) IncRequestCount(method, host, statusCode string) {
+	collector.mu.Lock()
+	collector.requestCount.WithLabelValues(method, host, statusCode).Inc()
+	collector.mu.Unlock()
+}
+
+func (collector *HttpClientCollector) ObserveResponseTime(method, host, statusCode string, duration time.Duration) {
+	collector.mu.Lock()
+	collector.responseTime.WithLabelValues(method, host, statusCode).Observe(float64(duration) / float64(time.Second))
+	collector.mu.Unlock()
+}
@@ -1,6 +1,7 @@
 package httpcollector
 
 import (
+	"fmt"
 	"strconv"
 	"sync"
 	"time"
@@ -15,17 +16,25 @@ const (
 	HttpRequestsTotal           = "requests_total"
 	HttpRequestDurationSeconds  = "request_duration_seconds"
 	HttpRequestsInProgressTotal = "requests_in_progress_total"
+	HttpRequestSizeBytes        = "request_size_bytes"
+	HttpResponseSizeBytes       = "response_size_bytes"
 
 	HttpRequestsHelp                = "Total number of HTTP requests."
 	HttpRequestsDurationSecondsHelp = "Duration of HTTP requests."
 	HttpRequestsInProgressHelp      = "Number of HTTP requests in progress."
+	HttpRequestSizeHelp             = "Size of HTTP request bodies."
+	HttpResponseSizeHelp            = "Size of HTTP response bodies."
 
 	HttpStatusCodeLabel = "status_code"
 	HttpMethodLabel     = "method"
+	HttpRouteLabel      = "route"
 )
 
 var (
 	httpCollector *HttpCollector
+
+	// bodySizeBuckets spans 64B to 16MiB.
+	bodySizeBuckets = prometheus.ExponentialBuckets(64, 4, 10)
 )
 
 type HttpCollector struct {
@@ -33,6 +42,8 @@ type HttpCollector struct {
 	requestCount     *prometheus.CounterVec
 	responseTime     *prometheus.HistogramVec
 	requestsInFlight *prometheus.GaugeVec
+	requestSize      *prometheus.HistogramVec
+	responseSize     *prometheus.HistogramVec
 }
 
 func newHttpCollector(serviceName string) *HttpCollector {
@@ -41,7 +52,7 @@ func newHttpCollector(serviceName string) *HttpCollector {
 			Name: prometheus.BuildFQName(serviceName, HttpSubsystem, HttpRequestsTotal),
 			Help: HttpRequestsHelp,
 		},
-		[]string{HttpMethodLabel, HttpStatusCodeLabel},
+		[]string{HttpMethodLabel, HttpRouteLabel, HttpStatusCodeLabel},
 	)
 
 	responseTime := prometheus.NewHistogramVec(
@@ -50,7 +61,7 @@ func newHttpCollector(serviceName string) *HttpCollector {
 			Help:    HttpRequestsDurationSecondsHelp,
 			Buckets: prometheus.DefBuckets,
 		},
-		[]string{HttpMethodLabel, HttpStatusCodeLabel},
+		[]string{HttpMethodLabel, HttpRouteLabel, HttpStatusCodeLabel},
 	)
 
 	requestsInFlight := prometheus.NewGaugeVec(
@@ -58,56 +69,114 @@ func newHttpCollector(serviceName string) *HttpCollector {
 			Name: prometheus.BuildFQName(serviceName, HttpSubsystem, HttpRequestsInProgressTotal),
 			Help: HttpRequestsInProgressHelp,
 		},
-		[]string{HttpMethodLabel},
+		[]string{HttpMethodLabel, HttpRouteLabel},
+	)
+
+	requestSize := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    prometheus.BuildFQName(serviceName, HttpSubsystem, HttpRequestSizeBytes),
+			Help:    HttpRequestSizeHelp,
+			Buckets: bodySizeBuckets,
+		},
+		[]string{HttpMethodLabel, HttpRouteLabel, HttpStatusCodeLabel},
+	)
+
+	responseSize := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    prometheus.BuildFQName(serviceName, HttpSubsystem, HttpResponseSizeBytes),
+			Help:    HttpResponseSizeHelp,
+			Buckets: bodySizeBuckets,
+		},
+		[]string{HttpMethodLabel, HttpRouteLabel, HttpStatusCodeLabel},
 	)
 
 	httpCollector = &HttpCollector{
 		requestCount:     requestCount,
 		responseTime:     responseTime,
 		requestsInFlight: requestsInFlight,
+		requestSize:      requestSize,
+		responseSize:     responseSize,
 	}
 
 	return httpCollector
 }
 
-func (collector *HttpCollector) Register(registry *prometheus.Registry) {
-	registry.MustRegister(collector.requestCount)
-	registry.MustRegister(collector.responseTime)
-	registry.MustRegister(collector.requestsInFlight)
+func (collector *HttpCollector) Register(registry *prometheus.Registry) error {
+	for _, c := range []prometheus.Collector{
+		collector.requestCount, collector.responseTime, collector.requestsInFlight,
+		collector.requestSize, collector.responseSize,
+	} {
+		if err := registry.Register(c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (collector *HttpCollector) Unregister(registry *prometheus.Registry) {
+	registry.Unregister(collector.requestCount)
+	registry.Unregister(collector.responseTime)
+	registry.Unregister(collector.requestsInFlight)
+	registry.Unregister(collector.requestSize)
+	registry.Unregister(collector.responseSize)
 }
 
-func Setup(registry *prometheus.Registry, serviceName string) {
+func Setup(registry *prometheus.Registry, serviceName string) error {
 	logger := logging.LoggerWithProcess("HttpCollectorSetup")
 	logger.Info("Setting up HTTP metrics...")
-	newHttpCollector(serviceName).Register(registry)
+	if err := newHttpCollector(serviceName).Register(registry); err != nil {
+		return fmt.Errorf("register HTTP collector: %w", err)
+	}
 
 	logger.Info("HTTP metrics setup complete")
+	return nil
+}
+
+func Teardown(registry *prometheus.Registry) {
+	if httpCollector == nil {
+		return
+	}
+
+	httpCollector.Unregister(registry)
 }
 
 func GetHttpCollector() *HttpCollector {
 	return httpCollector
 }
 
-func (collector *HttpCollector) IncRequestCount(method string, statusCode int) {
+func (collector *HttpCollector) IncRequestCount(method, route string, statusCode int) {
+	collector.mu.Lock()
+	collector.requestCount.WithLabelValues(method, route, strconv.Itoa(statusCode)).Inc()
+	collector.mu.Unlock()
+}
+
+func (collector *HttpCollector) ObserveResponseTime(method, route string, statusCode int, duration time.Duration) {
+	collector.mu.Lock()
+	collector.responseTime.WithLabelValues(method, route, strconv.Itoa(statusCode)).Observe(float64(duration) / float64(time.Second))
+	collector.mu.Unlock()
+}
+
+func (collector *HttpCollector) IncRequestsInFlight(method, route string) {
 	collector.mu.Lock()
-	collector.requestCount.WithLabelValues(method, strconv.Itoa(statusCode)).Inc()
+	collector.requestsInFlight.WithLabelValues(method, route).Inc()
 	collector.mu.Unlock()
 }
 
-func (collector *HttpCollector) ObserveResponseTime(method string, statusCode int, duration time.Duration) {
+func (collector *HttpCollector) DecRequestsInFlight(method, route string) {
 	collector.mu.Lock()
-	collector.responseTime.WithLabelValues(method, strconv.Itoa(statusCode)).Observe(float64(duration) / float64(time.Second))
+	collector.requestsInFlight.WithLabelValues(method, route).Dec()
 	collector.mu.Unlock()
 }
 
-func (collector *HttpCollector) IncRequestsInFlight(method string) {
+func (collector *HttpCollector) ObserveRequestSize(method, route string, statusCode int, size int) {
 	collector.mu.Lock()
-	collector.requestsInFlight.WithLabelValues(method).Inc()
+	collector.requestSize.WithLabelValues(method, route, strconv.Itoa(statusCode)).Observe(float64(size))
 	collector.mu.Unlock()
 }
 
-func (collector *HttpCollector) DecRequestsInFlight(method string) {
+func (collector *HttpCollector) ObserveResponseSize(method, route string, statusCode int, size int) {
 	collector.mu.Lock()
-	collector.requestsInFlight.WithLabelValues(method).Dec()
+	collector.responseSize.WithLabelValues(method, route, strconv.Itoa(statusCode)).Observe(float64(size))
 	collector.mu.Unlock()
 }
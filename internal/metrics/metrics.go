@@ -1,34 +1,90 @@
 package metrics
 
 import (
+	"context"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/todesdev/go-obs/internal/logging"
+	exportercollector "github.com/todesdev/go-obs/internal/metrics/exporter_collector"
 	grpccollector "github.com/todesdev/go-obs/internal/metrics/grpc_collector"
+	httpclientcollector "github.com/todesdev/go-obs/internal/metrics/http_client_collector"
 	httpcollector "github.com/todesdev/go-obs/internal/metrics/http_collector"
 	natscollector "github.com/todesdev/go-obs/internal/metrics/nats_collector"
+	paniccollector "github.com/todesdev/go-obs/internal/metrics/panic_collector"
 	systemcollector "github.com/todesdev/go-obs/internal/metrics/system_collector"
 )
 
-func Setup(serviceName string, http bool, grpc bool, nats bool) *prometheus.Registry {
+var registry *prometheus.Registry
+
+// Collectors selects the optional collectors registered by Setup in addition to the
+// system and panic collectors.
+type Collectors struct {
+	HTTP            bool
+	HTTPClient      bool
+	GRPC            bool
+	NATS            bool
+	TracingExporter bool
+}
+
+// Setup creates the registry and registers the requested collectors. On failure the
+// collectors registered so far are removed again.
+func Setup(serviceName string, collectors Collectors) (*prometheus.Registry, error) {
 	logger := logging.LoggerWithProcess("MetricsSetup")
 	logger.Info("Setting up metrics...")
 
-	registry := prometheus.NewRegistry()
+	registry = prometheus.NewRegistry()
 
-	systemcollector.Setup(registry, serviceName)
-	if http {
-		httpcollector.Setup(registry, serviceName)
+	setups := []func(*prometheus.Registry, string) error{systemcollector.Setup, paniccollector.Setup}
+	if collectors.HTTP {
+		setups = append(setups, httpcollector.Setup)
 	}
 
-	if grpc {
-		grpccollector.Setup(registry, serviceName)
+	if collectors.HTTPClient {
+		setups = append(setups, httpclientcollector.Setup)
 	}
 
-	if nats {
-		natscollector.Setup(registry, serviceName)
+	if collectors.GRPC {
+		setups = append(setups, grpccollector.Setup)
+	}
+
+	if collectors.NATS {
+		setups = append(setups, natscollector.Setup)
+	}
+
+	if collectors.TracingExporter {
+		setups = append(setups, exportercollector.Setup)
+	}
+
+	for _, setup := range setups {
+		if err := setup(registry, serviceName); err != nil {
+			_ = Shutdown(context.Background())
+			return nil, err
+		}
 	}
 
 	logger.Info("Metrics setup complete")
 
-	return registry
+	return registry, nil
+}
+
+// Shutdown stops the standalone metrics server, if any, and unregisters every collector
+// from the registry created by Setup.
+func Shutdown(ctx context.Context) error {
+	err := shutdownServer(ctx)
+
+	if registry == nil {
+		return err
+	}
+
+	systemcollector.Teardown(registry)
+	paniccollector.Teardown(registry)
+	httpcollector.Teardown(registry)
+	httpclientcollector.Teardown(registry)
+	grpccollector.Teardown(registry)
+	natscollector.Teardown(registry)
+	exportercollector.Teardown(registry)
+
+	registry = nil
+
+	return err
 }
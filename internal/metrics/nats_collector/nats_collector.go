@@ -1,6 +1,7 @@
 package natscollector
 
 import (
+	"fmt"
 	"sync"
 	"time"
 
@@ -14,16 +15,43 @@ const (
 	NatsProcessedMessagesTotal    = "processed_messages_total"
 	NatsMessageProcessingDuration = "message_processing_duration_seconds"
 	NatsPublishedMessagesTotal    = "published_messages_total"
+	NatsRequestDuration           = "request_duration_seconds"
+	NatsSettledMessagesTotal      = "settled_messages_total"
+	NatsDeadLetteredMessagesTotal = "dead_lettered_messages_total"
+	NatsInFlightMessages          = "in_flight_messages"
+	NatsQueueWaitDuration         = "queue_wait_duration_seconds"
 
 	NatsMessagesTotalHelp             = "Total number of NATS messages processed."
 	NatsMessageProcessingDurationHelp = "Duration of NATS message processing."
 	NatsPublishedMessagesHelp         = "Total number of NATS messages published."
+	NatsRequestDurationHelp           = "Duration of NATS requests until a reply, timeout or failure."
+	NatsSettledMessagesHelp           = "Total number of JetStream messages settled, by outcome."
+	NatsDeadLetteredMessagesHelp      = "Total number of messages republished to a dead-letter subject."
+	NatsInFlightMessagesHelp          = "Number of messages received by a subscriber and not yet settled."
+	NatsQueueWaitDurationHelp         = "Time messages wait for a subscriber worker before being processed."
 
 	NatsSubjectLabel = "subject"
 	NatsTypeLabel    = "type"
+	NatsResultLabel  = "result"
+	NatsOutcomeLabel = "outcome"
 
 	NatsSimpleMessageType    = "simple"
 	NatsJetStreamMessageType = "jetstream"
+
+	NatsRequestResultOK           = "ok"
+	NatsRequestResultTimeout      = "timeout"
+	NatsRequestResultNoResponders = "no_responders"
+	NatsRequestResultError        = "error"
+
+	NatsOutcomeAck            = "ack"
+	NatsOutcomeNak            = "nak"
+	NatsOutcomeTerm           = "term"
+	NatsOutcomeExhausted      = "exhausted"
+	NatsOutcomeFailed         = "settle_failed"
+	NatsOutcomeAlreadySettled = "already_settled"
+
+	NatsDeadLetterResultOK    = "ok"
+	NatsDeadLetterResultError = "error"
 )
 
 var natsCollector *NATSCollector
@@ -33,6 +61,11 @@ type NATSCollector struct {
 	processedMessages  *prometheus.CounterVec
 	processingDuration *prometheus.HistogramVec
 	publishedMessages  *prometheus.CounterVec
+	requestDuration    *prometheus.HistogramVec
+	settledMessages    *prometheus.CounterVec
+	deadLettered       *prometheus.CounterVec
+	inFlightMessages   *prometheus.GaugeVec
+	queueWait          *prometheus.HistogramVec
 }
 
 func newNATSCollector(serviceName string) *NATSCollector {
@@ -61,28 +94,101 @@ func newNATSCollector(serviceName string) *NATSCollector {
 		[]string{NatsTypeLabel, NatsSubjectLabel},
 	)
 
+	requestDuration := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    prometheus.BuildFQName(serviceName, NatsSubsystem, NatsRequestDuration),
+			Help:    NatsRequestDurationHelp,
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{NatsSubjectLabel, NatsResultLabel},
+	)
+
+	settledMessages := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: prometheus.BuildFQName(serviceName, NatsSubsystem, NatsSettledMessagesTotal),
+			Help: NatsSettledMessagesHelp,
+		},
+		[]string{NatsTypeLabel, NatsSubjectLabel, NatsOutcomeLabel},
+	)
+
+	deadLettered := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: prometheus.BuildFQName(serviceName, NatsSubsystem, NatsDeadLetteredMessagesTotal),
+			Help: NatsDeadLetteredMessagesHelp,
+		},
+		[]string{NatsSubjectLabel, NatsResultLabel},
+	)
+
+	inFlightMessages := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: prometheus.BuildFQName(serviceName, NatsSubsystem, NatsInFlightMessages),
+			Help: NatsInFlightMessagesHelp,
+		},
+		[]string{NatsTypeLabel, NatsSubjectLabel},
+	)
+
+	queueWait := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    prometheus.BuildFQName(serviceName, NatsSubsystem, NatsQueueWaitDuration),
+			Help:    NatsQueueWaitDurationHelp,
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{NatsTypeLabel, NatsSubjectLabel},
+	)
+
 	natsCollector = &NATSCollector{
 		processedMessages:  processedMessages,
 		processingDuration: processingDuration,
 		publishedMessages:  publishedMessages,
+		requestDuration:    requestDuration,
+		settledMessages:    settledMessages,
+		deadLettered:       deadLettered,
+		inFlightMessages:   inFlightMessages,
+		queueWait:          queueWait,
 	}
 
 	return natsCollector
 }
 
-func (collector *NATSCollector) Register(registry *prometheus.Registry) {
-	registry.MustRegister(collector.processedMessages)
-	registry.MustRegister(collector.processingDuration)
-	registry.MustRegister(collector.publishedMessages)
+func (collector *NATSCollector) Register(registry *prometheus.Registry) error {
+	for _, c := range []prometheus.Collector{collector.processedMessages, collector.processingDuration, collector.publishedMessages, collector.requestDuration, collector.settledMessages, collector.deadLettered, collector.inFlightMessages, collector.queueWait} {
+		if err := registry.Register(c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (collector *NATSCollector) Unregister(registry *prometheus.Registry) {
+	registry.Unregister(collector.processedMessages)
+	registry.Unregister(collector.processingDuration)
+	registry.Unregister(collector.publishedMessages)
+	registry.Unregister(collector.requestDuration)
+	registry.Unregister(collector.settledMessages)
+	registry.Unregister(collector.deadLettered)
+	registry.Unregister(collector.inFlightMessages)
+	registry.Unregister(collector.queueWait)
 }
 
-func Setup(registry *prometheus.Registry, serviceName string) {
+func Setup(registry *prometheus.Registry, serviceName string) error {
 	logger := logging.LoggerWithProcess("NatsCollectorSetup")
 	logger.Info("Setting up NATS collector")
 
-	newNATSCollector(serviceName).Register(registry)
+	if err := newNATSCollector(serviceName).Register(registry); err != nil {
+		return fmt.Errorf("register NATS collector: %w", err)
+	}
 
 	logger.Info("NATS collector setup complete")
+	return nil
+}
+
+func Teardown(registry *prometheus.Registry) {
+	if natsCollector == nil {
+		return
+	}
+
+	natsCollector.Unregister(registry)
 }
 
 func GetNATSCollector() *NATSCollector {
@@ -106,3 +212,39 @@ func (collector *NATSCollector) PublishedMessagesInc(subject string, messageType
 	collector.publishedMessages.WithLabelValues(messageType, subject).Inc()
 	collector.mu.Unlock()
 }
+
+func (collector *NATSCollector) RequestDurationObserve(subject string, result string, duration time.Duration) {
+	collector.mu.Lock()
+	collector.requestDuration.WithLabelValues(subject, result).Observe(float64(duration) / float64(time.Second))
+	collector.mu.Unlock()
+}
+
+func (collector *NATSCollector) SettledMessagesInc(subject string, messageType string, outcome string) {
+	collector.mu.Lock()
+	collector.settledMessages.WithLabelValues(messageType, subject, outcome).Inc()
+	collector.mu.Unlock()
+}
+
+func (collector *NATSCollector) DeadLetteredMessagesInc(subject string, result string) {
+	collector.mu.Lock()
+	collector.deadLettered.WithLabelValues(subject, result).Inc()
+	collector.mu.Unlock()
+}
+
+func (collector *NATSCollector) InFlightMessagesInc(subject string, messageType string) {
+	collector.mu.Lock()
+	collector.inFlightMessages.WithLabelValues(messageType, subject).Inc()
+	collector.mu.Unlock()
+}
+
+func (collector *NATSCollector) InFlightMessagesDec(subject string, messageType string) {
+	collector.mu.Lock()
+	collector.inFlightMessages.WithLabelValues(messageType, subject).Dec()
+	collector.mu.Unlock()
+}
+
+func (collector *NATSCollector) QueueWaitObserve(subject string, messageType string, duration time.Duration) {
+	collector.mu.Lock()
+	collector.queueWait.WithLabelValues(messageType, subject).Observe(float64(duration) / float64(time.Second))
+	collector.mu.Unlock()
+}
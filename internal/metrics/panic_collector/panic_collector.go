@@ -0,0 +1,81 @@
+package paniccollector
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/todesdev/go-obs/internal/logging"
+)
+
+const (
+	PanicsTotal = "panics_total"
+
+	PanicsTotalHelp = "Total number of panics recovered by the instrumentation."
+
+	PanicComponentLabel = "component"
+
+	ComponentHTTP = "http"
+	ComponentGRPC = "grpc"
+	ComponentNATS = "nats"
+)
+
+var panicCollector *PanicCollector
+
+type PanicCollector struct {
+	mu     sync.Mutex
+	panics *prometheus.CounterVec
+}
+
+func newPanicCollector(serviceName string) *PanicCollector {
+	panics := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: prometheus.BuildFQName(serviceName, "", PanicsTotal),
+			Help: PanicsTotalHelp,
+		},
+		[]string{PanicComponentLabel},
+	)
+
+	panicCollector = &PanicCollector{
+		panics: panics,
+	}
+
+	return panicCollector
+}
+
+func (collector *PanicCollector) Register(registry *prometheus.Registry) error {
+	return registry.Register(collector.panics)
+}
+
+func (collector *PanicCollector) Unregister(registry *prometheus.Registry) {
+	registry.Unregister(collector.panics)
+}
+
+func Setup(registry *prometheus.Registry, serviceName string) error {
+	logger := logging.LoggerWithProcess("PanicCollectorSetup")
+	logger.Info("Setting up panic metrics...")
+	if err := newPanicCollector(serviceName).Register(registry); err != nil {
+		return fmt.Errorf("register panic collector: %w", err)
+	}
+
+	logger.Info("Panic metrics setup complete")
+	return nil
+}
+
+func Teardown(registry *prometheus.Registry) {
+	if panicCollector == nil {
+		return
+	}
+
+	panicCollector.Unregister(registry)
+}
+
+func GetPanicCollector() *PanicCollector {
+	return panicCollector
+}
+
+func (collector *PanicCollector) IncPanics(component string) {
+	collector.mu.Lock()
+	collector.panics.WithLabelValues(component).Inc()
+	collector.mu.Unlock()
+}
@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/todesdev/go-obs/internal/logging"
+	"go.uber.org/zap"
+)
+
+var server *http.Server
+
+// Handler serves the registry created by Setup. The registry is resolved on every
+// request, so the handler can be mounted before Initialize runs.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if registry == nil {
+			http.Error(w, "metrics are not enabled", http.StatusServiceUnavailable)
+			return
+		}
+
+		promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+	})
+}
+
+// Serve exposes the registry on endpoint through a standalone HTTP server listening on
+// address. The listener is bound before returning so address errors are reported to
+// the caller; requests are served in the background until Shutdown.
+func Serve(registry *prometheus.Registry, address, endpoint string) error {
+	logger := logging.LoggerWithProcess("MetricsServer")
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(endpoint, promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	server = srv
+
+	go func() {
+		if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("Metrics server stopped", zap.Error(err))
+		}
+	}()
+
+	logger.Info("Metrics server listening", zap.String("address", listener.Addr().String()), zap.String("endpoint", endpoint))
+	return nil
+}
+
+func shutdownServer(ctx context.Context) error {
+	if server == nil {
+		return nil
+	}
+
+	err := server.Shutdown(ctx)
+	server = nil
+
+	return err
+}
@@ -1,6 +1,7 @@
 package systemcollector
 
 import (
+	"fmt"
 	"runtime"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -16,6 +17,8 @@ const (
 	SystemGoRoutineCountHelp = "Number of go routines."
 )
 
+var systemCollector *MetricsCollector
+
 type MetricsCollector struct {
 	gcStatsDesc        *prometheus.Desc
 	goRoutineCountDesc *prometheus.Desc
@@ -53,11 +56,23 @@ func (c *MetricsCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.goRoutineCountDesc
 }
 
-func Setup(registry *prometheus.Registry, serviceName string) {
+func Setup(registry *prometheus.Registry, serviceName string) error {
 	logger := logging.LoggerWithProcess("MetricsSystemCollector")
 	logger.Info("Setting up system metrics...")
 
-	registry.MustRegister(newCollector(serviceName))
+	systemCollector = newCollector(serviceName)
+	if err := registry.Register(systemCollector); err != nil {
+		return fmt.Errorf("register system collector: %w", err)
+	}
 
 	logger.Info("System metrics setup complete")
+	return nil
+}
+
+func Teardown(registry *prometheus.Registry) {
+	if systemCollector == nil {
+		return
+	}
+
+	registry.Unregister(systemCollector)
 }
@@ -3,7 +3,9 @@ package observer
 import (
 	"context"
 	"github.com/todesdev/go-obs/internal/logging"
+	"github.com/todesdev/go-obs/internal/requestid"
 	"github.com/todesdev/go-obs/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
@@ -13,6 +15,22 @@ type Observer struct {
 	ctx  context.Context
 	span trace.Span
 	log  *logging.Logger
+	// borrowed is set when the span was started by someone else (e.g. the otelgrpc stats
+	// handler), who is then also responsible for ending it.
+	borrowed bool
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying obs, retrievable with FromContext.
+func NewContext(ctx context.Context, obs *Observer) context.Context {
+	return context.WithValue(ctx, contextKey{}, obs)
+}
+
+// FromContext returns the Observer attached by NewContext, or nil if there is none.
+func FromContext(ctx context.Context) *Observer {
+	obs, _ := ctx.Value(contextKey{}).(*Observer)
+	return obs
 }
 
 var tracingEnabled = false
@@ -24,31 +42,61 @@ func SetTracingEnabled(enabled bool) {
 func InternalObserver(ctx context.Context, process string) *Observer {
 	obs := &Observer{}
 
-	return obs.observeInternal(ctx, process)
+	return obs.observeInternal(ctx, process).withRequestID()
 }
 
 func ServerObserver(ctx context.Context, process string) *Observer {
 	obs := &Observer{}
 
-	return obs.observeServer(ctx, process)
+	return obs.observeServer(ctx, process).withRequestID()
 }
 
 func ClientObserver(ctx context.Context, process string) *Observer {
 	obs := &Observer{}
 
-	return obs.observeClient(ctx, process)
+	return obs.observeClient(ctx, process).withRequestID()
 }
 
 func ProducerObserver(ctx context.Context, process string) *Observer {
 	obs := &Observer{}
 
-	return obs.observeProducer(ctx, process)
+	return obs.observeProducer(ctx, process).withRequestID()
 }
 
 func ConsumerObserver(ctx context.Context, process string) *Observer {
 	obs := &Observer{}
 
-	return obs.observeConsumer(ctx, process)
+	return obs.observeConsumer(ctx, process).withRequestID()
+}
+
+// ActiveSpanObserver wraps the span already active in ctx instead of starting a new one.
+// End does not end that span.
+func ActiveSpanObserver(ctx context.Context, process string) *Observer {
+	obs := &Observer{ctx: ctx}
+
+	span := trace.SpanFromContext(ctx)
+	if tracingEnabled && span.SpanContext().IsValid() {
+		obs.span = span
+		obs.log = logging.TracedLoggerWithProcess(span, process)
+		obs.borrowed = true
+		return obs.withRequestID()
+	}
+
+	if tracingEnabled {
+		return obs.observeServer(ctx, process).withRequestID()
+	}
+
+	obs.log = logging.LoggerWithProcess(process)
+	return obs.withRequestID()
+}
+
+// withRequestID adds the request ID carried by the observer's context to its logger.
+func (o *Observer) withRequestID() *Observer {
+	if id := requestid.FromContext(o.ctx); id != "" {
+		o.log = o.log.With(zap.String("requestID", id))
+	}
+
+	return o
 }
 
 func (o *Observer) observeInternal(ctx context.Context, process string) *Observer {
@@ -159,6 +207,18 @@ func (o *Observer) RecordErrorWithLogging(msg string, err error, fields ...zap.F
 	o.log.Error(msg, fields...)
 }
 
+// RecordPanicWithLogging records a recovered panic on the span, including the stack of
+// the panicking goroutine, marks the span as failed and logs it.
+func (o *Observer) RecordPanicWithLogging(msg string, err error, stack []byte, fields ...zap.Field) {
+	if tracingEnabled {
+		o.span.RecordError(err, trace.WithAttributes(attribute.String("exception.stacktrace", string(stack))))
+		o.span.SetStatus(codes.Error, err.Error())
+	}
+
+	fields = append(fields, zap.Error(err), zap.ByteString("stack", stack))
+	o.log.Error(msg, fields...)
+}
+
 func (o *Observer) LogInfo(msg string, fields ...zap.Field) {
 	o.log.Info(msg, fields...)
 }
@@ -177,12 +237,31 @@ func (o *Observer) LogFatal(msg string, err error, fields ...zap.Field) {
 	o.log.Fatal(msg, fields...)
 }
 
+func (o *Observer) SetStatus(code codes.Code, description string) {
+	if tracingEnabled {
+		o.span.SetStatus(code, description)
+	}
+}
+
+func (o *Observer) SetAttributes(attrs ...attribute.KeyValue) {
+	if tracingEnabled {
+		o.span.SetAttributes(attrs...)
+	}
+}
+
+// SetSpanName renames the span, e.g. once the route that handled a request is known.
+func (o *Observer) SetSpanName(name string) {
+	if tracingEnabled {
+		o.span.SetName(name)
+	}
+}
+
 func (o *Observer) Ctx() context.Context {
 	return o.ctx
 }
 
 func (o *Observer) End() {
-	if tracingEnabled {
+	if tracingEnabled && !o.borrowed {
 		o.span.End()
 	}
 }
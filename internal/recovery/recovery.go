@@ -0,0 +1,34 @@
+package recovery
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	paniccollector "github.com/todesdev/go-obs/internal/metrics/panic_collector"
+)
+
+// PanicError is the error a recovered panic is converted into.
+type PanicError struct {
+	Component string
+	Value     interface{}
+	Stack     []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic recovered in %s: %v", e.Component, e.Value)
+}
+
+// Recovered converts the value returned by recover() into a PanicError carrying the
+// stack of the panicking goroutine and counts it in the panics metric. It must be
+// called from the deferred function that recovered.
+func Recovered(component string, recovered interface{}) *PanicError {
+	if collector := paniccollector.GetPanicCollector(); collector != nil {
+		collector.IncPanics(component)
+	}
+
+	return &PanicError{
+		Component: component,
+		Value:     recovered,
+		Stack:     debug.Stack(),
+	}
+}
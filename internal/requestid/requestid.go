@@ -0,0 +1,63 @@
+package requestid
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const DefaultHeader = "X-Request-ID"
+
+var header = DefaultHeader
+
+// SetHeader sets the HTTP and NATS header carrying the request ID. An empty name keeps
+// the current one.
+func SetHeader(name string) {
+	if name != "" {
+		header = name
+	}
+}
+
+func Header() string {
+	return header
+}
+
+// MetadataKey is the gRPC metadata key carrying the request ID; metadata keys are lower case.
+func MetadataKey() string {
+	return strings.ToLower(header)
+}
+
+const maxLength = 128
+
+func New() string {
+	return uuid.NewString()
+}
+
+// Ensure returns id if it is a usable request ID received from a peer, or a newly
+// generated one when it is empty, too long or contains non-printable characters.
+func Ensure(id string) string {
+	if id == "" || len(id) > maxLength {
+		return New()
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < ' ' || id[i] > '~' {
+			return New()
+		}
+	}
+
+	return id
+}
+
+type contextKey struct{}
+
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID stored in ctx, or an empty string.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
@@ -0,0 +1,136 @@
+package tracing
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+
+	defaultDialTimeout          = 2 * time.Second
+	defaultRetryInitialInterval = 5 * time.Second
+	defaultRetryMaxInterval     = 30 * time.Second
+	defaultRetryMaxElapsedTime  = time.Minute
+)
+
+// ConnectionOptions configures transport security, headers, compression and timeouts
+// of the connection to the OTLP collector. TLS is used when TLSEnabled is set or any
+// certificate file is given; without a CA file the host's root CAs are trusted.
+// Failed exports are retried with exponential backoff; zero retry values use the defaults.
+type ConnectionOptions struct {
+	TLSEnabled           bool
+	CACertFile           string
+	ClientCertFile       string
+	ClientKeyFile        string
+	Headers              map[string]string
+	Compression          string
+	Timeout              time.Duration
+	RetryDisabled        bool
+	RetryInitialInterval time.Duration
+	RetryMaxInterval     time.Duration
+	RetryMaxElapsedTime  time.Duration
+}
+
+type retryConfig struct {
+	Enabled         bool
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	MaxElapsedTime  time.Duration
+}
+
+func (o ConnectionOptions) validate() error {
+	switch o.Compression {
+	case "", CompressionNone, CompressionGzip:
+	default:
+		return fmt.Errorf("unsupported otlp compression %q", o.Compression)
+	}
+
+	if (o.ClientCertFile == "") != (o.ClientKeyFile == "") {
+		return errors.New("otlp client certificate and key must be set together")
+	}
+
+	if o.Timeout < 0 {
+		return fmt.Errorf("otlp timeout %v must not be negative", o.Timeout)
+	}
+
+	if o.RetryInitialInterval < 0 || o.RetryMaxInterval < 0 || o.RetryMaxElapsedTime < 0 {
+		return errors.New("otlp retry intervals must not be negative")
+	}
+
+	return nil
+}
+
+func (o ConnectionOptions) tlsEnabled() bool {
+	return o.TLSEnabled || o.CACertFile != "" || o.ClientCertFile != ""
+}
+
+func (o ConnectionOptions) gzipEnabled() bool {
+	return o.Compression == CompressionGzip
+}
+
+func (o ConnectionOptions) dialTimeout() time.Duration {
+	if o.Timeout > 0 {
+		return o.Timeout
+	}
+
+	return defaultDialTimeout
+}
+
+func (o ConnectionOptions) retryMaxInterval() time.Duration {
+	if o.RetryMaxInterval > 0 {
+		return o.RetryMaxInterval
+	}
+
+	return defaultRetryMaxInterval
+}
+
+func (o ConnectionOptions) retryConfig() retryConfig {
+	cfg := retryConfig{
+		Enabled:         !o.RetryDisabled,
+		InitialInterval: defaultRetryInitialInterval,
+		MaxInterval:     o.retryMaxInterval(),
+		MaxElapsedTime:  defaultRetryMaxElapsedTime,
+	}
+
+	if o.RetryInitialInterval > 0 {
+		cfg.InitialInterval = o.RetryInitialInterval
+	}
+	if o.RetryMaxElapsedTime > 0 {
+		cfg.MaxElapsedTime = o.RetryMaxElapsedTime
+	}
+
+	return cfg
+}
+
+func (o ConnectionOptions) tlsConfig() (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if o.CACertFile != "" {
+		pem, err := os.ReadFile(o.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("read otlp CA certificate: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in otlp CA file %q", o.CACertFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if o.ClientCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.ClientCertFile, o.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load otlp client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
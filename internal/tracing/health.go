@@ -0,0 +1,78 @@
+package tracing
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/todesdev/go-obs/internal/logging"
+	exportercollector "github.com/todesdev/go-obs/internal/metrics/exporter_collector"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// healthExporter reports the outcome of every export through the exporter metrics and
+// logs when the exporter transitions between healthy and failing, so a collector outage
+// is visible without flooding the logs on every failed batch.
+type healthExporter struct {
+	sdktrace.SpanExporter
+	failing atomic.Bool
+	logger  *logging.Logger
+}
+
+func newHealthExporter(exporter sdktrace.SpanExporter) *healthExporter {
+	return &healthExporter{
+		SpanExporter: exporter,
+		logger:       logging.LoggerWithProcess("TracingExporter"),
+	}
+}
+
+func (e *healthExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	err := e.SpanExporter.ExportSpans(ctx, spans)
+
+	collector := exportercollector.GetExporterCollector()
+	if err != nil {
+		if collector != nil {
+			collector.SetUp(false)
+			collector.AddExportedSpans(exportercollector.ExporterResultFailure, len(spans))
+		}
+		if !e.failing.Swap(true) {
+			e.logger.Error("Failed to export spans, dropping batch", zap.Int("spans", len(spans)), zap.Error(err))
+		}
+		return err
+	}
+
+	if collector != nil {
+		collector.SetUp(true)
+		collector.AddExportedSpans(exportercollector.ExporterResultSuccess, len(spans))
+	}
+	if e.failing.Swap(false) {
+		e.logger.Info("Span export recovered")
+	}
+
+	return nil
+}
+
+// watchConnection logs connectivity changes of the collector connection and keeps
+// it reconnecting in the background. It returns once the connection is closed.
+func watchConnection(conn *grpc.ClientConn, endpoint string) {
+	logger := logging.LoggerWithProcess("TracingExporter")
+	ctx := context.Background()
+
+	state := conn.GetState()
+	for conn.WaitForStateChange(ctx, state) {
+		state = conn.GetState()
+
+		switch state {
+		case connectivity.Ready:
+			logger.Info("Connected to OTLP collector", zap.String("endpoint", endpoint))
+		case connectivity.TransientFailure:
+			logger.Warn("OTLP collector is unreachable, retrying in background", zap.String("endpoint", endpoint))
+		case connectivity.Idle:
+			conn.Connect()
+		case connectivity.Shutdown:
+			return
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package tracing
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/todesdev/go-obs/internal/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	HttpEncodingProtobuf = "protobuf"
+	HttpEncodingJSON     = "json"
+
+	defaultTracesURLPath = "/v1/traces"
+	defaultHttpTimeout   = 10 * time.Second
+)
+
+// SetupOtlpHttpTracer exports spans to an OTLP/HTTP collector. The endpoint may be a
+// host:port pair or a full URL; when no path is given the default /v1/traces is used.
+func SetupOtlpHttpTracer(tracingHTTPEndpoint, encoding, serviceName string, res *resource.Resource, sampler sdktrace.Sampler, connOpts ConnectionOptions) error {
+	logger := logging.LoggerWithProcess("TracingSetup")
+	logger.Info("Setting up OTLP HTTP tracing", zap.String("encoding", encoding))
+
+	if err := connOpts.validate(); err != nil {
+		logger.Error("Invalid OTLP connection options", zap.Error(err))
+		return err
+	}
+
+	ctx := context.Background()
+	endpointURL, err := otlpHttpEndpointURL(tracingHTTPEndpoint, connOpts.tlsEnabled())
+	if err != nil {
+		logger.Error("Invalid OTLP HTTP endpoint", zap.Error(err))
+		return err
+	}
+
+	exporter, err := newOtlpHttpExporter(ctx, endpointURL, encoding, connOpts)
+	if err != nil {
+		logger.Error("Failed to create OTLP HTTP exporter", zap.Error(err))
+		return err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithBatcher(newHealthExporter(exporter)),
+		sdktrace.WithResource(res),
+	)
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	service = serviceName
+	tracerProvider = tp
+
+	logger.Info("Tracing setup complete")
+	return nil
+}
+
+func otlpHttpEndpointURL(endpoint string, tlsEnabled bool) (*url.URL, error) {
+	if endpoint == "" {
+		return nil, fmt.Errorf("otlp http endpoint is empty")
+	}
+
+	if !strings.Contains(endpoint, "://") {
+		if tlsEnabled {
+			endpoint = "https://" + endpoint
+		} else {
+			endpoint = "http://" + endpoint
+		}
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported otlp http scheme %q", u.Scheme)
+	}
+
+	if u.Host == "" {
+		return nil, fmt.Errorf("otlp http endpoint %q has no host", endpoint)
+	}
+
+	if u.Path == "" || u.Path == "/" {
+		u.Path = defaultTracesURLPath
+	}
+
+	return u, nil
+}
+
+func newOtlpHttpExporter(ctx context.Context, endpointURL *url.URL, encoding string, connOpts ConnectionOptions) (*otlptrace.Exporter, error) {
+	var tlsCfg *tls.Config
+	if endpointURL.Scheme == "https" {
+		cfg, err := connOpts.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		tlsCfg = cfg
+	}
+
+	switch encoding {
+	case "", HttpEncodingProtobuf:
+		exporterOpts := []otlptracehttp.Option{
+			otlptracehttp.WithEndpointURL(endpointURL.String()),
+			otlptracehttp.WithRetry(otlptracehttp.RetryConfig(connOpts.retryConfig())),
+		}
+		if tlsCfg != nil {
+			exporterOpts = append(exporterOpts, otlptracehttp.WithTLSClientConfig(tlsCfg))
+		}
+		if len(connOpts.Headers) > 0 {
+			exporterOpts = append(exporterOpts, otlptracehttp.WithHeaders(connOpts.Headers))
+		}
+		if connOpts.gzipEnabled() {
+			exporterOpts = append(exporterOpts, otlptracehttp.WithCompression(otlptracehttp.GzipCompression))
+		}
+		if connOpts.Timeout > 0 {
+			exporterOpts = append(exporterOpts, otlptracehttp.WithTimeout(connOpts.Timeout))
+		}
+
+		return otlptracehttp.New(ctx, exporterOpts...)
+	case HttpEncodingJSON:
+		return otlptrace.New(ctx, newJSONClient(endpointURL.String(), tlsCfg, connOpts))
+	default:
+		return nil, fmt.Errorf("unsupported otlp http encoding %q", encoding)
+	}
+}
+
+// jsonClient is an otlptrace.Client that uploads spans using the OTLP/HTTP JSON encoding,
+// which the upstream otlptracehttp exporter does not support.
+type jsonClient struct {
+	endpoint string
+	headers  map[string]string
+	gzip     bool
+	retry    retryConfig
+	client   *http.Client
+}
+
+func newJSONClient(endpoint string, tlsCfg *tls.Config, connOpts ConnectionOptions) *jsonClient {
+	timeout := connOpts.Timeout
+	if timeout == 0 {
+		timeout = defaultHttpTimeout
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsCfg
+
+	return &jsonClient{
+		endpoint: endpoint,
+		headers:  connOpts.Headers,
+		gzip:     connOpts.gzipEnabled(),
+		retry:    connOpts.retryConfig(),
+		client:   &http.Client{Transport: transport, Timeout: timeout},
+	}
+}
+
+func (c *jsonClient) Start(ctx context.Context) error {
+	return nil
+}
+
+func (c *jsonClient) Stop(ctx context.Context) error {
+	c.client.CloseIdleConnections()
+	return nil
+}
+
+// UploadTraces retries network errors and throttling or unavailability responses with
+// exponential backoff until the retry budget or the context is exhausted.
+func (c *jsonClient) UploadTraces(ctx context.Context, protoSpans []*tracepb.ResourceSpans) error {
+	body, err := c.encode(protoSpans)
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(c.retry.MaxElapsedTime)
+	interval := c.retry.InitialInterval
+	for {
+		retryable, err := c.upload(ctx, body)
+		if err == nil || !retryable || !c.retry.Enabled {
+			return err
+		}
+
+		if time.Now().Add(interval).After(deadline) {
+			return fmt.Errorf("retry budget exhausted: %w", err)
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(ctx.Err(), err)
+		case <-timer.C:
+		}
+
+		interval = min(interval*2, c.retry.MaxInterval)
+	}
+}
+
+func (c *jsonClient) encode(protoSpans []*tracepb.ResourceSpans) ([]byte, error) {
+	body, err := protojson.Marshal(&coltracepb.ExportTraceServiceRequest{ResourceSpans: protoSpans})
+	if err != nil {
+		return nil, err
+	}
+
+	if !c.gzip {
+		return body, nil
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(body); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (c *jsonClient) upload(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests,
+		resp.StatusCode == http.StatusBadGateway,
+		resp.StatusCode == http.StatusServiceUnavailable,
+		resp.StatusCode == http.StatusGatewayTimeout:
+		return true, fmt.Errorf("otlp http collector responded with status %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("otlp http collector responded with status %d", resp.StatusCode)
+	}
+}
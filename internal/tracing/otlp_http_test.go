@@ -0,0 +1,178 @@
+package tracing
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+type collectedRequest struct {
+	path            string
+	contentType     string
+	contentEncoding string
+	header          http.Header
+	body            []byte
+}
+
+// newCollector starts a stand-in OTLP/HTTP collector that records every request.
+func newCollector(t *testing.T) (*httptest.Server, <-chan collectedRequest) {
+	t.Helper()
+
+	requests := make(chan collectedRequest, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("read request body: %v", err)
+		}
+
+		requests <- collectedRequest{
+			path:            r.URL.Path,
+			contentType:     r.Header.Get("Content-Type"),
+			contentEncoding: r.Header.Get("Content-Encoding"),
+			header:          r.Header.Clone(),
+			body:            body,
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, requests
+}
+
+func TestOtlpHttpExporter(t *testing.T) {
+	tests := []struct {
+		name            string
+		encoding        string
+		compression     string
+		path            string
+		wantPath        string
+		wantContentType string
+	}{
+		{
+			name:            "protobuf",
+			encoding:        HttpEncodingProtobuf,
+			wantPath:        defaultTracesURLPath,
+			wantContentType: "application/x-protobuf",
+		},
+		{
+			name:            "protobuf gzip custom path",
+			encoding:        HttpEncodingProtobuf,
+			compression:     CompressionGzip,
+			path:            "/otlp/v1/traces",
+			wantPath:        "/otlp/v1/traces",
+			wantContentType: "application/x-protobuf",
+		},
+		{
+			name:            "json",
+			encoding:        HttpEncodingJSON,
+			wantPath:        defaultTracesURLPath,
+			wantContentType: "application/json",
+		},
+		{
+			name:            "json gzip custom path",
+			encoding:        HttpEncodingJSON,
+			compression:     CompressionGzip,
+			path:            "/otlp/v1/traces",
+			wantPath:        "/otlp/v1/traces",
+			wantContentType: "application/json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, requests := newCollector(t)
+
+			endpointURL, err := otlpHttpEndpointURL(strings.TrimPrefix(srv.URL, "http://")+tt.path, false)
+			if err != nil {
+				t.Fatalf("endpoint url: %v", err)
+			}
+
+			connOpts := ConnectionOptions{
+				Headers:       map[string]string{"Authorization": "Bearer token", "X-Tenant": "tenant"},
+				Compression:   tt.compression,
+				RetryDisabled: true,
+			}
+
+			ctx := context.Background()
+			exporter, err := newOtlpHttpExporter(ctx, endpointURL, tt.encoding, connOpts)
+			if err != nil {
+				t.Fatalf("new exporter: %v", err)
+			}
+
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+			_, span := tp.Tracer("test").Start(ctx, "test-span")
+			span.End()
+			if err := tp.Shutdown(ctx); err != nil {
+				t.Fatalf("shutdown: %v", err)
+			}
+
+			var req collectedRequest
+			select {
+			case req = <-requests:
+			default:
+				t.Fatal("collector received no request")
+			}
+
+			if req.path != tt.wantPath {
+				t.Errorf("path = %q, want %q", req.path, tt.wantPath)
+			}
+			if req.contentType != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", req.contentType, tt.wantContentType)
+			}
+			for k, v := range connOpts.Headers {
+				if got := req.header.Get(k); got != v {
+					t.Errorf("header %s = %q, want %q", k, got, v)
+				}
+			}
+
+			body := req.body
+			if tt.compression == CompressionGzip {
+				if req.contentEncoding != "gzip" {
+					t.Fatalf("Content-Encoding = %q, want gzip", req.contentEncoding)
+				}
+
+				gz, err := gzip.NewReader(bytes.NewReader(body))
+				if err != nil {
+					t.Fatalf("gzip reader: %v", err)
+				}
+				if body, err = io.ReadAll(gz); err != nil {
+					t.Fatalf("gunzip body: %v", err)
+				}
+			} else if req.contentEncoding != "" {
+				t.Errorf("Content-Encoding = %q, want none", req.contentEncoding)
+			}
+
+			var export coltracepb.ExportTraceServiceRequest
+			if tt.encoding == HttpEncodingJSON {
+				err = protojson.Unmarshal(body, &export)
+			} else {
+				err = proto.Unmarshal(body, &export)
+			}
+			if err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+
+			var names []string
+			for _, rs := range export.GetResourceSpans() {
+				for _, ss := range rs.GetScopeSpans() {
+					for _, s := range ss.GetSpans() {
+						names = append(names, s.GetName())
+					}
+				}
+			}
+			if len(names) != 1 || names[0] != "test-span" {
+				t.Errorf("exported spans = %v, want [test-span]", names)
+			}
+		})
+	}
+}
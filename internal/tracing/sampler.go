@@ -0,0 +1,113 @@
+package tracing
+
+import (
+	"fmt"
+	"path"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	SamplerAlwaysOn                = "always_on"
+	SamplerAlwaysOff               = "always_off"
+	SamplerTraceIDRatio            = "traceidratio"
+	SamplerParentBasedAlwaysOn     = "parentbased_always_on"
+	SamplerParentBasedAlwaysOff    = "parentbased_always_off"
+	SamplerParentBasedTraceIDRatio = "parentbased_traceidratio"
+)
+
+// SamplingRule samples every span whose name matches Pattern with the given Ratio.
+// Pattern uses path.Match syntax, e.g. "HTTP:GET:/health" or "NATS Consumer:orders.*".
+type SamplingRule struct {
+	Pattern string
+	Ratio   float64
+}
+
+// NewSampler builds a sampler for the given strategy. Rules are evaluated in order
+// before the strategy's own decision; with a parent-based strategy they only apply to root spans.
+func NewSampler(strategy string, ratio float64, rules []SamplingRule) (sdktrace.Sampler, error) {
+	if ratio < 0 || ratio > 1 {
+		return nil, fmt.Errorf("sampling ratio %v is out of range [0, 1]", ratio)
+	}
+
+	var base sdktrace.Sampler
+	parentBased := false
+
+	switch strategy {
+	case "", SamplerAlwaysOn:
+		base = sdktrace.AlwaysSample()
+	case SamplerAlwaysOff:
+		base = sdktrace.NeverSample()
+	case SamplerTraceIDRatio:
+		base = sdktrace.TraceIDRatioBased(ratio)
+	case SamplerParentBasedAlwaysOn:
+		base = sdktrace.AlwaysSample()
+		parentBased = true
+	case SamplerParentBasedAlwaysOff:
+		base = sdktrace.NeverSample()
+		parentBased = true
+	case SamplerParentBasedTraceIDRatio:
+		base = sdktrace.TraceIDRatioBased(ratio)
+		parentBased = true
+	default:
+		return nil, fmt.Errorf("unknown sampling strategy %q", strategy)
+	}
+
+	if len(rules) > 0 {
+		rs, err := newRuleSampler(rules, base)
+		if err != nil {
+			return nil, err
+		}
+		base = rs
+	}
+
+	if parentBased {
+		return sdktrace.ParentBased(base), nil
+	}
+
+	return base, nil
+}
+
+type samplingRule struct {
+	pattern string
+	sampler sdktrace.Sampler
+}
+
+type ruleSampler struct {
+	rules    []samplingRule
+	fallback sdktrace.Sampler
+}
+
+func newRuleSampler(rules []SamplingRule, fallback sdktrace.Sampler) (*ruleSampler, error) {
+	rs := &ruleSampler{fallback: fallback}
+
+	for _, rule := range rules {
+		if _, err := path.Match(rule.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid sampling rule pattern %q: %w", rule.Pattern, err)
+		}
+		if rule.Ratio < 0 || rule.Ratio > 1 {
+			return nil, fmt.Errorf("sampling rule %q ratio %v is out of range [0, 1]", rule.Pattern, rule.Ratio)
+		}
+
+		rs.rules = append(rs.rules, samplingRule{
+			pattern: rule.Pattern,
+			sampler: sdktrace.TraceIDRatioBased(rule.Ratio),
+		})
+	}
+
+	return rs, nil
+}
+
+func (rs *ruleSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	for _, rule := range rs.rules {
+		if matched, _ := path.Match(rule.pattern, p.Name); matched {
+			return rule.sampler.ShouldSample(p)
+		}
+	}
+
+	return rs.fallback.ShouldSample(p)
+}
+
+func (rs *ruleSampler) Description() string {
+	return fmt.Sprintf("RuleSampler{rules=%d,fallback=%s}", len(rs.rules), rs.fallback.Description())
+}
@@ -2,8 +2,7 @@ package tracing
 
 import (
 	"context"
-	"net"
-	"time"
+	"errors"
 
 	"github.com/todesdev/go-obs/internal/logging"
 	"go.opentelemetry.io/otel"
@@ -15,7 +14,10 @@ import (
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding/gzip"
 )
 
 const (
@@ -26,26 +28,40 @@ const (
 	SpanConsumer = trace.SpanKindConsumer
 )
 
-var service string
+var (
+	service        string
+	tracerProvider *sdktrace.TracerProvider
+	collectorConn  *grpc.ClientConn
+)
 
-func SetupOtlpGrpcTracer(tracingGPRCEndpoint, serviceName string, res *resource.Resource) error {
+func SetupOtlpGrpcTracer(tracingGPRCEndpoint, serviceName string, res *resource.Resource, sampler sdktrace.Sampler, connOpts ConnectionOptions) error {
 	logger := logging.LoggerWithProcess("TracingSetup")
 	logger.Info("Setting up OLTP GRPC tracing")
 
+	if err := connOpts.validate(); err != nil {
+		logger.Error("Invalid OTLP connection options", zap.Error(err))
+		return err
+	}
+
 	ctx := context.Background()
-	conn, err := connectToOTLPCollector(ctx, tracingGPRCEndpoint)
+	conn, err := connectToOTLPCollector(ctx, tracingGPRCEndpoint, connOpts)
 	if err != nil {
-		logger.Fatal("Failed to connect to OTLP collector", zap.Error(err))
+		logger.Error("Failed to create OTLP collector connection", zap.Error(err))
 		return err
 	}
 
-	tp, err := configureOtlpGrpcTraceProvider(ctx, conn, res)
+	tp, err := configureOtlpGrpcTraceProvider(ctx, conn, res, sampler, connOpts)
 	if err != nil {
-		logger.Fatal("Failed to configure trace provider", zap.Error(err))
+		_ = conn.Close()
+		logger.Error("Failed to configure trace provider", zap.Error(err))
 		return err
 	}
 
+	go watchConnection(conn, tracingGPRCEndpoint)
+
 	service = serviceName
+	tracerProvider = tp
+	collectorConn = conn
 
 	otel.SetTracerProvider(tp)
 	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
@@ -54,13 +70,13 @@ func SetupOtlpGrpcTracer(tracingGPRCEndpoint, serviceName string, res *resource.
 	return nil
 }
 
-func SetupStdOutTracer(serviceName string, res *resource.Resource) error {
+func SetupStdOutTracer(serviceName string, res *resource.Resource, sampler sdktrace.Sampler) error {
 	logger := logging.LoggerWithProcess("TracingSetup")
 	logger.Info("Setting up STDOUT tracing")
 
-	tp, err := configureStdOutTraceProvider(res)
+	tp, err := configureStdOutTraceProvider(res, sampler)
 	if err != nil {
-		logger.Fatal("Failed to configure trace provider", zap.Error(err))
+		logger.Error("Failed to configure trace provider", zap.Error(err))
 		return err
 	}
 
@@ -68,11 +84,36 @@ func SetupStdOutTracer(serviceName string, res *resource.Resource) error {
 	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
 
 	service = serviceName
+	tracerProvider = tp
 
 	logger.Info("Tracing setup complete")
 	return nil
 }
 
+// Shutdown flushes the spans queued in the batch processor, stops the tracer provider
+// and closes the connection to the OTLP collector. The context deadline bounds the flush.
+func Shutdown(ctx context.Context) error {
+	if tracerProvider == nil {
+		return nil
+	}
+
+	var errs []error
+	if err := tracerProvider.Shutdown(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	if collectorConn != nil {
+		if err := collectorConn.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	tracerProvider = nil
+	collectorConn = nil
+
+	return errors.Join(errs...)
+}
+
 func NewTrace(ctx context.Context, spanKind trace.SpanKind, processName string) (context.Context, trace.Span) {
 	return otel.Tracer(service).Start(ctx, processName, trace.WithSpanKind(spanKind))
 }
@@ -97,48 +138,75 @@ func NewConsumerTrace(ctx context.Context, processName string) (context.Context,
 	return otel.Tracer(service).Start(ctx, processName, trace.WithSpanKind(SpanConsumer))
 }
 
-func connectToOTLPCollector(ctx context.Context, tracingGRPCEndpoint string) (*grpc.ClientConn, error) {
-
-	timeout := 2 * time.Second
-	conn, err := net.DialTimeout("tcp", tracingGRPCEndpoint, timeout)
+// connectToOTLPCollector creates the collector connection without waiting for it to become
+// ready. gRPC keeps reconnecting with backoff in the background, so the service starts
+// even when the collector is temporarily down.
+func connectToOTLPCollector(ctx context.Context, tracingGRPCEndpoint string, connOpts ConnectionOptions) (*grpc.ClientConn, error) {
+	dialOpts, err := grpcDialOptions(connOpts)
 	if err != nil {
 		return nil, err
 	}
 
-	defer func(conn net.Conn) {
-		err := conn.Close()
+	backoffCfg := backoff.DefaultConfig
+	backoffCfg.MaxDelay = connOpts.retryMaxInterval()
+	dialOpts = append(dialOpts, grpc.WithConnectParams(grpc.ConnectParams{
+		Backoff:           backoffCfg,
+		MinConnectTimeout: connOpts.dialTimeout(),
+	}))
+
+	return grpc.DialContext(ctx, tracingGRPCEndpoint, dialOpts...)
+}
+
+func grpcDialOptions(connOpts ConnectionOptions) ([]grpc.DialOption, error) {
+	creds := insecure.NewCredentials()
+	if connOpts.tlsEnabled() {
+		tlsCfg, err := connOpts.tlsConfig()
 		if err != nil {
-			return
+			return nil, err
 		}
-	}(conn)
+		creds = credentials.NewTLS(tlsCfg)
+	}
 
-	return grpc.DialContext(ctx, tracingGRPCEndpoint,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithBlock(),
-	)
+	dialOpts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	if connOpts.gzipEnabled() {
+		dialOpts = append(dialOpts, grpc.WithDefaultCallOptions(grpc.UseCompressor(gzip.Name)))
+	}
+
+	return dialOpts, nil
 }
 
-func configureOtlpGrpcTraceProvider(ctx context.Context, conn *grpc.ClientConn, res *resource.Resource) (*sdktrace.TracerProvider, error) {
-	exporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithGRPCConn(conn))
+func configureOtlpGrpcTraceProvider(ctx context.Context, conn *grpc.ClientConn, res *resource.Resource, sampler sdktrace.Sampler, connOpts ConnectionOptions) (*sdktrace.TracerProvider, error) {
+	exporterOpts := []otlptracegrpc.Option{
+		otlptracegrpc.WithGRPCConn(conn),
+		otlptracegrpc.WithRetry(otlptracegrpc.RetryConfig(connOpts.retryConfig())),
+	}
+	if len(connOpts.Headers) > 0 {
+		exporterOpts = append(exporterOpts, otlptracegrpc.WithHeaders(connOpts.Headers))
+	}
+	if connOpts.Timeout > 0 {
+		exporterOpts = append(exporterOpts, otlptracegrpc.WithTimeout(connOpts.Timeout))
+	}
+
+	exporter, err := otlptracegrpc.New(ctx, exporterOpts...)
 	if err != nil {
 		return nil, err
 	}
 
 	return sdktrace.NewTracerProvider(
-		sdktrace.WithSampler(sdktrace.AlwaysSample()),
-		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithBatcher(newHealthExporter(exporter)),
 		sdktrace.WithResource(res),
 	), nil
 }
 
-func configureStdOutTraceProvider(res *resource.Resource) (*sdktrace.TracerProvider, error) {
+func configureStdOutTraceProvider(res *resource.Resource, sampler sdktrace.Sampler) (*sdktrace.TracerProvider, error) {
 	exporter, err := stdouttrace.New()
 	if err != nil {
 		return nil, err
 	}
 
 	return sdktrace.NewTracerProvider(
-		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+		sdktrace.WithSampler(sampler),
 		sdktrace.WithBatcher(exporter),
 		sdktrace.WithResource(res),
 	), nil
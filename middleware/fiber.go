@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"github.com/todesdev/go-obs/internal/logging"
 	"github.com/todesdev/go-obs/internal/observer"
 	"net/http"
@@ -9,43 +10,81 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	httpcollector "github.com/todesdev/go-obs/internal/metrics/http_collector"
+	"github.com/todesdev/go-obs/internal/recovery"
+	"github.com/todesdev/go-obs/internal/requestid"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+// ErrorMapper translates an error returned by a handler before it is passed to the
+// app's ErrorHandler, e.g. to turn domain errors into *fiber.Error values with the
+// right status code. Returning nil treats the request as successful.
+type ErrorMapper func(c *fiber.Ctx, err error) error
+
+// Config configures ObservabilityWithConfig. Filters select requests for which some
+// or all instrumentation is skipped; when nil, /metrics, /health and /ready are
+// skipped. MetricsEndpoint, if set, is always skipped. TraceResponseHeaders apply to
+// traced requests only.
+type Config struct {
+	TracingEnabled       bool
+	MetricsEnabled       bool
+	ErrorMapper          ErrorMapper
+	Filters              []Filter
+	MetricsEndpoint      string
+	TraceResponseHeaders TraceResponseHeaders
+}
+
 func Observability(tracingEnabled bool, metricsEnabled bool) fiber.Handler {
+	return ObservabilityWithConfig(Config{TracingEnabled: tracingEnabled, MetricsEnabled: metricsEnabled})
+}
+
+// ObservabilityWithConfig returns the Fiber observability middleware. Handler errors
+// are passed to the app's ErrorHandler inside the middleware, so the status code it
+// produces is the one recorded in metrics and on the span; the middleware itself then
+// returns nil.
+func ObservabilityWithConfig(cfg Config) fiber.Handler {
+	filters := newFilterChain(cfg.Filters, cfg.MetricsEndpoint)
+	routes := &fiberRouteResolver{}
+
 	return func(c *fiber.Ctx) error {
-		if shouldSkipPath(c) {
+		skip := filters.skipMode(c)
+		if skip == SkipAll {
 			return c.Next()
 		}
 
+		reqCfg := cfg
+		reqCfg.TracingEnabled = cfg.TracingEnabled && skip&SkipTracing == 0
+		reqCfg.MetricsEnabled = cfg.MetricsEnabled && skip&SkipMetrics == 0
+		logRequest := skip&SkipLogging == 0
+
 		startTime := time.Now()
 		reqHeader := extractHeaders(c)
-		processName := getProcessName(c)
-
-		if tracingEnabled {
-			ctx, obs := setupTracing(c, reqHeader, processName)
+		ownRoute := c.Route()
+		processName := getProcessName(c.Method(), c.Path())
+		requestID := fiberRequestID(c)
+
+		if reqCfg.TracingEnabled {
+			// The span starts with the route the request is expected to reach, which
+			// sampling rules match on, and is renamed after the handler chain completes.
+			ctx, obs := setupTracing(requestid.NewContext(c.Context(), requestID), reqHeader, getProcessName(c.Method(), routes.resolve(c)))
 			defer obs.End()
+			obs.SetAttributes(fiberRequestAttributes(c)...)
 			c.SetUserContext(ctx)
-			obs.LogInfo("Request received")
-			return processRequest(c, startTime, metricsEnabled, obs)
+			if logRequest {
+				obs.LogInfo("Request received")
+			}
+			return processRequest(c, reqCfg, ownRoute, startTime, logRequest, obs)
 		}
 
-		logger := logging.LoggerWithProcess(processName)
-		logger.Info("Request received")
-		return processRequest(c, startTime, metricsEnabled, logger)
-	}
-}
-
-func shouldSkipPath(c *fiber.Ctx) bool {
-	pathsToSkip := []string{"/metrics", "/health", "/ready"}
-	for _, path := range pathsToSkip {
-		if c.Path() == path {
-			return true
+		c.SetUserContext(requestid.NewContext(c.UserContext(), requestID))
+		logger := logging.LoggerWithProcess(processName).With(zap.String("requestID", requestID))
+		if logRequest {
+			logger.Info("Request received")
 		}
+		return processRequest(c, reqCfg, ownRoute, startTime, logRequest, logger)
 	}
-	return false
 }
 
 func extractHeaders(c *fiber.Ctx) http.Header {
@@ -56,54 +95,128 @@ func extractHeaders(c *fiber.Ctx) http.Header {
 	return reqHeader
 }
 
-func getProcessName(c *fiber.Ctx) string {
-	path := c.Path()
-	method := c.Route().Method
-	return "HTTP:" + method + ":" + path
+// fiberRequestID returns the request ID sent by the client, generating one if there is
+// no usable one, and echoes it in the response.
+func fiberRequestID(c *fiber.Ctx) string {
+	id := requestid.Ensure(c.Get(requestid.Header()))
+
+	c.Set(requestid.Header(), id)
+	return id
 }
 
-func setupTracing(c *fiber.Ctx, reqHeader http.Header, processName string) (context.Context, *observer.Observer) {
-	ctx := otel.GetTextMapPropagator().Extract(c.Context(), propagation.HeaderCarrier(reqHeader))
+func setupTracing(parent context.Context, reqHeader http.Header, processName string) (context.Context, *observer.Observer) {
+	ctx := otel.GetTextMapPropagator().Extract(parent, propagation.HeaderCarrier(reqHeader))
 	obs := observer.ServerObserver(ctx, processName)
 	return obs.Ctx(), obs
 }
 
-func processRequest(c *fiber.Ctx, startTime time.Time, metricsEnabled bool, loggerOrObserver interface{}) error {
-	if metricsEnabled {
-		metricsCollector := httpcollector.GetHttpCollector()
-		metricsCollector.IncRequestsInFlight(c.Method())
-		defer metricsCollector.DecRequestsInFlight(c.Method())
+func processRequest(c *fiber.Ctx, cfg Config, ownRoute *fiber.Route, startTime time.Time, logRequest bool, loggerOrObserver interface{}) error {
+	method := c.Method()
+
+	// A nil collector, e.g. with HTTP metrics not set up, disables the metrics.
+	var metricsCollector *httpcollector.HttpCollector
+	if cfg.MetricsEnabled {
+		metricsCollector = httpcollector.GetHttpCollector()
+	}
+
+	if metricsCollector != nil {
+		// The handling route is only known once the router has run, so requests in
+		// flight are labelled with the route the middleware runs on.
+		metricsCollector.IncRequestsInFlight(method, ownRoute.Path)
+		defer metricsCollector.DecRequestsInFlight(method, ownRoute.Path)
 	}
 
-	err := c.Next()
+	err := nextWithRecovery(c)
+	handledRoute := fiberRoute(c, ownRoute)
+
+	var panicErr *recovery.PanicError
+	if errors.As(err, &panicErr) {
+		// The panic details stay in the logs and on the span; the client only gets a
+		// generic 500 rendered by the app's ErrorHandler.
+		c.Response().ResetBody()
+		handleError(c, fiber.ErrInternalServerError)
+	} else if err != nil {
+		if cfg.ErrorMapper != nil {
+			err = cfg.ErrorMapper(c, err)
+		}
+		if err != nil {
+			handleError(c, err)
+		}
+	}
 
 	elapsedTime := time.Since(startTime)
 	statusCode := c.Response().StatusCode()
 
-	if metricsEnabled {
-		metricsCollector := httpcollector.GetHttpCollector()
-		metricsCollector.IncRequestCount(c.Method(), statusCode)
-		metricsCollector.ObserveResponseTime(c.Method(), statusCode, elapsedTime)
+	if metricsCollector != nil {
+		metricsCollector.IncRequestCount(method, handledRoute, statusCode)
+		metricsCollector.ObserveResponseTime(method, handledRoute, statusCode, elapsedTime)
+		metricsCollector.ObserveRequestSize(method, handledRoute, statusCode, fiberRequestSize(c))
+		if size, ok := fiberResponseSize(c); ok {
+			metricsCollector.ObserveResponseSize(method, handledRoute, statusCode, size)
+		}
 	}
 
+	// Panics are logged even for requests with logging skipped.
 	switch v := loggerOrObserver.(type) {
 	case *observer.Observer:
-		if err != nil {
-			v.RecordErrorWithLogging("Request error", err)
-		} else {
-			v.RecordInfoWithLogging("Request completed", zap.Int("statusCode", statusCode), zap.Duration("elapsedTime", elapsedTime))
+		v.SetSpanName(getProcessName(method, handledRoute))
+		if cfg.TraceResponseHeaders.enabled() {
+			setTraceResponseHeaders(c, cfg.TraceResponseHeaders, trace.SpanContextFromContext(v.Ctx()), elapsedTime)
 		}
-	case *zap.Logger:
-		if err != nil {
-			v.Error("Request error", zap.Error(err))
-		} else {
+		v.SetAttributes(responseAttributes(handledRoute, statusCode)...)
+		switch {
+		case panicErr != nil:
+			v.RecordPanicWithLogging("Request panicked", panicErr, panicErr.Stack, zap.Int("statusCode", statusCode))
+		case err != nil && logRequest:
+			v.RecordErrorWithLogging("Request error", err, zap.Int("statusCode", statusCode), zap.Duration("elapsedTime", elapsedTime))
+		case err != nil:
+			v.RecordError(err)
+		case logRequest:
+			v.LogInfo("Request completed", zap.Int("statusCode", statusCode), zap.Duration("elapsedTime", elapsedTime))
+		}
+		if panicErr == nil {
+			v.SetStatus(serverSpanStatus(statusCode))
+		}
+	case *logging.Logger:
+		switch {
+		case panicErr != nil:
+			v.Error("Request panicked", zap.Error(panicErr), zap.ByteString("stack", panicErr.Stack), zap.Int("statusCode", statusCode))
+		case !logRequest:
+		case err != nil:
+			v.Error("Request error", zap.Error(err), zap.Int("statusCode", statusCode), zap.Duration("elapsedTime", elapsedTime))
+		default:
 			v.Info("Request completed", zap.Int("statusCode", statusCode), zap.Duration("elapsedTime", elapsedTime))
 		}
 	}
 
-	if err != nil {
-		return c.Status(fiber.StatusNotFound).SendString("Sorry I can't find that!")
+	return nil
+}
+
+// fiberRequestSize returns the request body size from Content-Length, or the length of
+// the received body for chunked requests.
+func fiberRequestSize(c *fiber.Ctx) int {
+	if size := c.Request().Header.ContentLength(); size >= 0 {
+		return size
 	}
 
-	return nil
+	return len(c.Request().Body())
+}
+
+// fiberResponseSize returns the response body size. Streamed bodies are only measured
+// when the handler declared their Content-Length, as reading them would consume them.
+func fiberResponseSize(c *fiber.Ctx) (int, bool) {
+	if c.Response().IsBodyStream() {
+		size := c.Response().Header.ContentLength()
+		return size, size >= 0
+	}
+
+	return len(c.Response().Body()), true
+}
+
+// handleError renders err with the app's ErrorHandler, falling back to a bare 500 when
+// the ErrorHandler itself fails, the same way Fiber does for unhandled errors.
+func handleError(c *fiber.Ctx, err error) {
+	if handlerErr := c.App().ErrorHandler(c, err); handlerErr != nil {
+		_ = c.SendStatus(fiber.StatusInternalServerError)
+	}
 }
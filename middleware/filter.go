@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SkipMode selects which instrumentation a Filter turns off for the requests it matches.
+type SkipMode uint8
+
+const (
+	SkipTracing SkipMode = 1 << iota
+	SkipMetrics
+	SkipLogging
+
+	// SkipAll bypasses the middleware entirely. A zero Skip is treated as SkipAll.
+	SkipAll = SkipTracing | SkipMetrics | SkipLogging
+)
+
+// Filter matches requests by exact path, path prefix, path.Match glob pattern (where
+// "*" does not cross "/") or an arbitrary predicate, and turns off the instrumentation
+// selected by Skip for them. Func is the predicate of the Fiber middleware and
+// HTTPFunc that of the net/http one.
+type Filter struct {
+	Paths    []string
+	Prefixes []string
+	Patterns []string
+	Func     func(c *fiber.Ctx) bool
+	HTTPFunc func(r *http.Request) bool
+	Skip     SkipMode
+}
+
+func (f Filter) skipMode() SkipMode {
+	if f.Skip == 0 {
+		return SkipAll
+	}
+
+	return f.Skip
+}
+
+func (f Filter) matchPath(p string) bool {
+	for _, exact := range f.Paths {
+		if p == exact {
+			return true
+		}
+	}
+
+	for _, prefix := range f.Prefixes {
+		if strings.HasPrefix(p, prefix) {
+			return true
+		}
+	}
+
+	for _, pattern := range f.Patterns {
+		if matched, _ := path.Match(pattern, p); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f Filter) match(c *fiber.Ctx) bool {
+	return f.matchPath(c.Path()) || (f.Func != nil && f.Func(c))
+}
+
+func (f Filter) matchRequest(r *http.Request) bool {
+	return f.matchPath(r.URL.Path) || (f.HTTPFunc != nil && f.HTTPFunc(r))
+}
+
+// defaultFilters are used when Config.Filters or HTTPConfig.Filters is nil.
+var defaultFilters = []Filter{{Paths: pathsToSkip}}
+
+// filterChain combines the configured filters with the metrics endpoint, which is
+// never instrumented.
+type filterChain []Filter
+
+func newFilterChain(filters []Filter, metricsEndpoint string) filterChain {
+	if filters == nil {
+		filters = defaultFilters
+	}
+
+	chain := make(filterChain, 0, len(filters)+1)
+	if metricsEndpoint != "" {
+		chain = append(chain, Filter{Paths: []string{metricsEndpoint}})
+	}
+
+	return append(chain, filters...)
+}
+
+func (chain filterChain) skipMode(c *fiber.Ctx) SkipMode {
+	return chain.mode(func(f Filter) bool { return f.match(c) })
+}
+
+func (chain filterChain) requestSkipMode(r *http.Request) SkipMode {
+	return chain.mode(func(f Filter) bool { return f.matchRequest(r) })
+}
+
+func (chain filterChain) mode(match func(f Filter) bool) SkipMode {
+	var mode SkipMode
+	for _, f := range chain {
+		if match(f) {
+			mode |= f.skipMode()
+		}
+	}
+
+	return mode
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	httpcollector "github.com/todesdev/go-obs/internal/metrics/http_collector"
+)
+
+func TestHTTPObservabilityFilters(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	if err := httpcollector.Setup(registry, "test"); err != nil {
+		t.Fatalf("setup collector: %v", err)
+	}
+	t.Cleanup(func() { httpcollector.Teardown(registry) })
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name     string
+		filters  []Filter
+		path     string
+		header   string
+		recorded bool
+	}{
+		{name: "default health", path: "/health", recorded: false},
+		{name: "default metrics", path: "/metrics", recorded: false},
+		{name: "custom metrics endpoint", path: "/internal/metrics", recorded: false},
+		{name: "regular request", path: "/users", recorded: true},
+		{name: "prefix", filters: []Filter{{Prefixes: []string{"/static/"}}}, path: "/static/app.js", recorded: false},
+		{name: "pattern", filters: []Filter{{Patterns: []string{"/debug/*"}}}, path: "/debug/vars", recorded: false},
+		{name: "custom filters replace defaults", filters: []Filter{{Paths: []string{"/live"}}}, path: "/health", recorded: true},
+		{name: "custom filters keep metrics endpoint", filters: []Filter{{Paths: []string{"/live"}}}, path: "/internal/metrics", recorded: false},
+		{
+			name:     "request predicate",
+			filters:  []Filter{{HTTPFunc: func(r *http.Request) bool { return r.Header.Get("X-Probe") != "" }}},
+			path:     "/users",
+			header:   "1",
+			recorded: false,
+		},
+		{name: "metrics only skip", filters: []Filter{{Paths: []string{"/users"}, Skip: SkipMetrics}}, path: "/users", recorded: false},
+		{name: "tracing only skip", filters: []Filter{{Paths: []string{"/users"}, Skip: SkipTracing}}, path: "/users", recorded: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := HTTPObservabilityWithConfig(HTTPConfig{
+				MetricsEnabled:  true,
+				Filters:         tt.filters,
+				MetricsEndpoint: "/internal/metrics",
+			})(mux)
+
+			before := requestCount(t, registry)
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("X-Probe", tt.header)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got := requestCount(t, registry) > before; got != tt.recorded {
+				t.Errorf("request to %s recorded = %v, want %v", tt.path, got, tt.recorded)
+			}
+		})
+	}
+}
+
+func requestCount(t *testing.T, registry *prometheus.Registry) float64 {
+	t.Helper()
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+
+	var count float64
+	for _, family := range families {
+		if family.GetName() != "test_http_requests_total" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			count += m.GetCounter().GetValue()
+		}
+	}
+
+	return count
+}
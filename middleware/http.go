@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/todesdev/go-obs/internal/logging"
+	httpcollector "github.com/todesdev/go-obs/internal/metrics/http_collector"
+	"github.com/todesdev/go-obs/internal/observer"
+	"github.com/todesdev/go-obs/internal/requestid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.uber.org/zap"
+)
+
+// HTTPConfig configures HTTPObservabilityWithConfig. Filters and MetricsEndpoint
+// select skipped requests as in Config; Filter.HTTPFunc is the predicate used here.
+type HTTPConfig struct {
+	TracingEnabled  bool
+	MetricsEnabled  bool
+	Filters         []Filter
+	MetricsEndpoint string
+}
+
+// HTTPObservability is the net/http counterpart of Observability. It returns a
+// middleware usable with http.ServeMux wrappers and routers such as chi, applying the
+// same default skip paths and span naming as the Fiber middleware. Route templates
+// are taken from next when it is an *http.ServeMux.
+func HTTPObservability(tracingEnabled bool, metricsEnabled bool) func(http.Handler) http.Handler {
+	return HTTPObservabilityWithConfig(HTTPConfig{TracingEnabled: tracingEnabled, MetricsEnabled: metricsEnabled})
+}
+
+// HTTPObservabilityWithConfig is the net/http counterpart of ObservabilityWithConfig.
+func HTTPObservabilityWithConfig(cfg HTTPConfig) func(http.Handler) http.Handler {
+	filters := newFilterChain(cfg.Filters, cfg.MetricsEndpoint)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			skip := filters.requestSkipMode(r)
+			if skip == SkipAll {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			tracingEnabled := cfg.TracingEnabled && skip&SkipTracing == 0
+			metricsEnabled := cfg.MetricsEnabled && skip&SkipMetrics == 0
+			logRequest := skip&SkipLogging == 0
+
+			startTime := time.Now()
+			route := httpRoute(next, r)
+			processName := getProcessName(r.Method, route)
+			requestID := httpRequestID(w, r)
+			r = r.WithContext(requestid.NewContext(r.Context(), requestID))
+
+			if tracingEnabled {
+				ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+				obs := observer.ServerObserver(ctx, processName)
+				defer obs.End()
+				obs.SetAttributes(httpRequestAttributes(r)...)
+				if logRequest {
+					obs.LogInfo("Request received")
+				}
+				serveHTTPRequest(next, w, r.WithContext(obs.Ctx()), route, startTime, metricsEnabled, logRequest, obs)
+				return
+			}
+
+			logger := logging.LoggerWithProcess(processName).With(zap.String("requestID", requestID))
+			if logRequest {
+				logger.Info("Request received")
+			}
+			serveHTTPRequest(next, w, r, route, startTime, metricsEnabled, logRequest, logger)
+		})
+	}
+}
+
+// httpRequestID is the net/http counterpart of fiberRequestID.
+func httpRequestID(w http.ResponseWriter, r *http.Request) string {
+	id := requestid.Ensure(r.Header.Get(requestid.Header()))
+
+	w.Header().Set(requestid.Header(), id)
+	return id
+}
+
+func serveHTTPRequest(next http.Handler, w http.ResponseWriter, r *http.Request, route string, startTime time.Time, metricsEnabled bool, logRequest bool, loggerOrObserver interface{}) {
+	// A nil collector, e.g. with HTTP metrics not set up, disables the metrics.
+	var metricsCollector *httpcollector.HttpCollector
+	if metricsEnabled {
+		metricsCollector = httpcollector.GetHttpCollector()
+	}
+
+	if metricsCollector != nil {
+		metricsCollector.IncRequestsInFlight(r.Method, route)
+		defer metricsCollector.DecRequestsInFlight(r.Method, route)
+	}
+
+	rec := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+	panicErr := serveWithRecovery(next, rec, r)
+
+	elapsedTime := time.Since(startTime)
+	statusCode := rec.statusCode
+
+	if metricsCollector != nil {
+		metricsCollector.IncRequestCount(r.Method, route, statusCode)
+		metricsCollector.ObserveResponseTime(r.Method, route, statusCode, elapsedTime)
+		if r.ContentLength >= 0 {
+			metricsCollector.ObserveRequestSize(r.Method, route, statusCode, int(r.ContentLength))
+		}
+		metricsCollector.ObserveResponseSize(r.Method, route, statusCode, rec.bytesWritten)
+	}
+
+	// Panics are logged even for requests with logging skipped.
+	switch v := loggerOrObserver.(type) {
+	case *observer.Observer:
+		v.SetAttributes(responseAttributes(route, statusCode)...)
+		if panicErr != nil {
+			v.RecordPanicWithLogging("Request panicked", panicErr, panicErr.Stack)
+			return
+		}
+		if logRequest {
+			v.LogInfo("Request completed", zap.Int("statusCode", statusCode), zap.Duration("elapsedTime", elapsedTime))
+		}
+		v.SetStatus(serverSpanStatus(statusCode))
+	case *logging.Logger:
+		if panicErr != nil {
+			v.Error("Request panicked", zap.Error(panicErr), zap.ByteString("stack", panicErr.Stack))
+			return
+		}
+		if logRequest {
+			v.Info("Request completed", zap.Int("statusCode", statusCode), zap.Duration("elapsedTime", elapsedTime))
+		}
+	}
+}
+
+// statusRecorder captures the status code and body size written by the wrapped handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode   int
+	wroteHeader  bool
+	bytesWritten int
+}
+
+func (r *statusRecorder) WriteHeader(statusCode int) {
+	if !r.wroteHeader {
+		r.statusCode = statusCode
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(b)
+	r.bytesWritten += n
+	return n, err
+}
+
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
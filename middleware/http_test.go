@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	httpcollector "github.com/todesdev/go-obs/internal/metrics/http_collector"
+)
+
+func TestRequestsInFlightRoute(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	if err := httpcollector.Setup(registry, "test"); err != nil {
+		t.Fatalf("setup collector: %v", err)
+	}
+	t.Cleanup(func() { httpcollector.Teardown(registry) })
+
+	t.Run("net/http", func(t *testing.T) {
+		var during float64
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+			during = inFlight(t, registry, http.MethodGet, "/orders/{id}")
+		})
+		handler := HTTPObservabilityWithConfig(HTTPConfig{MetricsEnabled: true})(mux)
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders/1", nil))
+
+		if during != 1 {
+			t.Errorf("in flight during request = %v, want 1", during)
+		}
+		if after := inFlight(t, registry, http.MethodGet, "/orders/{id}"); after != 0 {
+			t.Errorf("in flight after request = %v, want 0", after)
+		}
+	})
+
+	t.Run("fiber", func(t *testing.T) {
+		var during float64
+		app := fiber.New()
+		app.Get("/items/:id", ObservabilityWithConfig(Config{MetricsEnabled: true}), func(c *fiber.Ctx) error {
+			during = inFlight(t, registry, http.MethodGet, "/items/:id")
+			return nil
+		})
+
+		if _, err := app.Test(httptest.NewRequest(http.MethodGet, "/items/1", nil)); err != nil {
+			t.Fatalf("request: %v", err)
+		}
+
+		if during != 1 {
+			t.Errorf("in flight during request = %v, want 1", during)
+		}
+		if after := inFlight(t, registry, http.MethodGet, "/items/:id"); after != 0 {
+			t.Errorf("in flight after request = %v, want 0", after)
+		}
+	})
+}
+
+// inFlight returns the requests in flight gauge for method and route, or -1 if the
+// series does not exist.
+func inFlight(t *testing.T, registry *prometheus.Registry, method, route string) float64 {
+	t.Helper()
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+
+	for _, family := range families {
+		if family.GetName() != "test_http_requests_in_progress_total" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if labels[httpcollector.HttpMethodLabel] == method && labels[httpcollector.HttpRouteLabel] == route {
+				return m.GetGauge().GetValue()
+			}
+		}
+	}
+
+	return -1
+}
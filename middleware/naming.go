@@ -0,0 +1,7 @@
+package middleware
+
+var pathsToSkip = []string{"/metrics", "/health", "/ready"}
+
+func getProcessName(method, path string) string {
+	return "HTTP:" + method + ":" + path
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	paniccollector "github.com/todesdev/go-obs/internal/metrics/panic_collector"
+	"github.com/todesdev/go-obs/internal/recovery"
+)
+
+// nextWithRecovery calls the next Fiber handler and converts a panic into a
+// *recovery.PanicError so the request can still be answered and recorded.
+func nextWithRecovery(c *fiber.Ctx) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recovery.Recovered(paniccollector.ComponentHTTP, r)
+		}
+	}()
+
+	return c.Next()
+}
+
+// serveWithRecovery is the net/http counterpart of nextWithRecovery. If the handler
+// panicked before writing a response, a 500 is written instead. http.ErrAbortHandler
+// is re-raised since it is how handlers deliberately abort a response.
+func serveWithRecovery(next http.Handler, rec *statusRecorder, r *http.Request) (panicErr *recovery.PanicError) {
+	defer func() {
+		if v := recover(); v != nil {
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+
+			panicErr = recovery.Recovered(paniccollector.ComponentHTTP, v)
+			if !rec.wroteHeader {
+				http.Error(rec, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+		}
+	}()
+
+	next.ServeHTTP(rec, r)
+	return nil
+}
@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// unmatchedRoute labels requests that no registered route matched.
+	unmatchedRoute = "unmatched"
+	// unknownRoute labels net/http requests whose handler does not expose route patterns.
+	unknownRoute = "unknown"
+)
+
+// fiberRoute returns the template of the route that handled the request, read from
+// c.Route() once the handler chain has run. It returns unmatchedRoute when no other
+// route was reached, i.e. c.Route() is still own, the middleware's route. A request
+// that only reached further middleware, such as that of a group, is labelled with the
+// middleware's prefix.
+func fiberRoute(c *fiber.Ctx, own *fiber.Route) string {
+	route := c.Route()
+	if route == own {
+		return unmatchedRoute
+	}
+
+	return route.Path
+}
+
+// fiberRouteResolver finds the template of the route a request will be dispatched to
+// before the router has run, so that the span can start with it and sampling rules
+// see the template rather than the path.
+type fiberRouteResolver struct {
+	table atomic.Pointer[fiberRouteTable]
+}
+
+// fiberRouteTable holds the templates of an app's routes, without middleware, in the
+// order the router tries them. handlers detects routes registered after it was built.
+type fiberRouteTable struct {
+	app      *fiber.App
+	handlers uint32
+	cfg      fiber.Config
+	routes   map[string][]fiberRouteTemplate
+}
+
+type fiberRouteTemplate struct {
+	path string
+	// prefix is the literal start of path, which every matching request path starts with.
+	prefix string
+}
+
+// resolve returns the template of the first route matching the request, or
+// unmatchedRoute. A route handler calling c.Next() may still pass the request on, so
+// fiberRoute has the final word once the handler chain has run.
+func (r *fiberRouteResolver) resolve(c *fiber.Ctx) string {
+	app := c.App()
+	table := r.table.Load()
+	if table == nil || table.app != app || table.handlers != app.HandlersCount() {
+		table = newFiberRouteTable(app)
+		r.table.Store(table)
+	}
+
+	path := c.Path()
+	for _, route := range table.routes[c.Method()] {
+		if !hasPrefix(path, route.prefix, table.cfg.CaseSensitive) {
+			continue
+		}
+		if fiber.RoutePatternMatch(path, route.path, table.cfg) {
+			return route.path
+		}
+	}
+
+	return unmatchedRoute
+}
+
+func newFiberRouteTable(app *fiber.App) *fiberRouteTable {
+	cfg := app.Config()
+	table := &fiberRouteTable{
+		app:      app,
+		handlers: app.HandlersCount(),
+		cfg:      fiber.Config{CaseSensitive: cfg.CaseSensitive, StrictRouting: cfg.StrictRouting},
+		routes:   make(map[string][]fiberRouteTemplate),
+	}
+
+	for _, route := range app.GetRoutes(true) {
+		prefix := route.Path
+		if i := strings.IndexAny(prefix, ":*+\\"); i >= 0 {
+			prefix = prefix[:i]
+		}
+		// Optional parameters and non-strict routing allow a missing trailing slash.
+		if len(prefix) > 1 {
+			prefix = strings.TrimRight(prefix, "/")
+		}
+
+		table.routes[route.Method] = append(table.routes[route.Method], fiberRouteTemplate{path: route.Path, prefix: prefix})
+	}
+
+	return table
+}
+
+func hasPrefix(s, prefix string, caseSensitive bool) bool {
+	if len(s) < len(prefix) {
+		return false
+	}
+	if caseSensitive {
+		return s[:len(prefix)] == prefix
+	}
+
+	return strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+// httpRoute returns the pattern of the http.ServeMux route matching r. Other handlers
+// do not expose their patterns, so their requests are labelled unknownRoute.
+func httpRoute(next http.Handler, r *http.Request) string {
+	mux, ok := next.(*http.ServeMux)
+	if !ok {
+		return unknownRoute
+	}
+
+	if _, pattern := mux.Handler(r); pattern != "" {
+		// Method-qualified patterns such as "GET /items/{id}" carry the method, which
+		// is already a separate label.
+		if _, path, found := strings.Cut(pattern, " "); found {
+			return path
+		}
+		return pattern
+	}
+
+	return unmatchedRoute
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestFiberRoute(t *testing.T) {
+	var got, resolved string
+
+	resolver := &fiberRouteResolver{}
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		own := c.Route()
+		resolved = resolver.resolve(c)
+		err := c.Next()
+		got = fiberRoute(c, own)
+		return err
+	})
+
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Get("/users/:id<int>", ok)
+	app.Get("/users/:name", ok)
+	app.Get("/files/*", ok)
+	app.Get("/flights/:from-:to", ok)
+	app.Get("/items/:id?", ok)
+	app.Get("/missing", func(c *fiber.Ctx) error { return fiber.ErrNotFound })
+
+	api := app.Group("/api")
+	api.Use(func(c *fiber.Ctx) error { return c.Next() })
+	api.Get("/orders/:id", ok)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		late   bool
+		want   string
+		// resolved is the route expected before dispatch, when it differs from want.
+		resolved string
+	}{
+		{name: "constrained param", method: http.MethodGet, path: "/users/42", want: "/users/:id<int>"},
+		{name: "constraint falls through", method: http.MethodGet, path: "/users/bob", want: "/users/:name"},
+		{name: "wildcard", method: http.MethodGet, path: "/files/a/b/c.txt", want: "/files/*"},
+		{name: "mixed segment", method: http.MethodGet, path: "/flights/LAX-SFO", want: "/flights/:from-:to"},
+		{name: "optional param present", method: http.MethodGet, path: "/items/7", want: "/items/:id?"},
+		{name: "optional param absent", method: http.MethodGet, path: "/items", want: "/items/:id?"},
+		{name: "handler not found error", method: http.MethodGet, path: "/missing", want: "/missing"},
+		{name: "group behind middleware", method: http.MethodGet, path: "/api/orders/1", want: "/api/orders/:id"},
+		{name: "group miss behind middleware", method: http.MethodGet, path: "/api/unknown", want: "/api", resolved: unmatchedRoute},
+		{name: "no route", method: http.MethodGet, path: "/nowhere", want: unmatchedRoute},
+		{name: "method not allowed", method: http.MethodPost, path: "/files/x", want: unmatchedRoute},
+		{name: "registered after first request", method: http.MethodGet, path: "/late/1", late: true, want: "/late/:id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.late {
+				app.Get("/late/:id", ok)
+			}
+
+			got, resolved = "", ""
+			if _, err := app.Test(httptest.NewRequest(tt.method, tt.path, nil)); err != nil {
+				t.Fatalf("request: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("fiberRoute(%s %s) = %q, want %q", tt.method, tt.path, got, tt.want)
+			}
+
+			wantResolved := tt.want
+			if tt.resolved != "" {
+				wantResolved = tt.resolved
+			}
+			if resolved != wantResolved {
+				t.Errorf("resolve(%s %s) = %q, want %q", tt.method, tt.path, resolved, wantResolved)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/todesdev/go-obs/internal/observer"
+	"github.com/todesdev/go-obs/internal/tracing"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestSamplingRulesMatchRouteTemplate checks that sampling rules written for a route
+// template apply to the requests the route handles, as the decision is taken when the
+// span starts.
+func TestSamplingRulesMatchRouteTemplate(t *testing.T) {
+	sampler, err := tracing.NewSampler(tracing.SamplerAlwaysOff, 0, []tracing.SamplingRule{
+		{Pattern: "HTTP:GET:/users/:id", Ratio: 1},
+		{Pattern: "HTTP:GET:/orders/{id}", Ratio: 1},
+	})
+	if err != nil {
+		t.Fatalf("new sampler: %v", err)
+	}
+
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSampler(sampler), sdktrace.WithSpanProcessor(recorder)))
+	observer.SetTracingEnabled(true)
+	t.Cleanup(func() {
+		observer.SetTracingEnabled(false)
+		otel.SetTracerProvider(previous)
+	})
+
+	app := fiber.New()
+	app.Use(ObservabilityWithConfig(Config{TracingEnabled: true}))
+	app.Get("/users/:id", func(c *fiber.Ctx) error { return nil })
+	app.Get("/teams/:id", func(c *fiber.Ctx) error { return nil })
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /orders/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("GET /invoices/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	handler := HTTPObservabilityWithConfig(HTTPConfig{TracingEnabled: true})(mux)
+
+	tests := []struct {
+		name    string
+		serve   func(path string)
+		path    string
+		sampled string
+	}{
+		{name: "fiber rule", serve: fiberServe(t, app), path: "/users/42", sampled: "HTTP:GET:/users/:id"},
+		{name: "fiber no rule", serve: fiberServe(t, app), path: "/teams/42"},
+		{name: "net/http rule", serve: httpServe(handler), path: "/orders/42", sampled: "HTTP:GET:/orders/{id}"},
+		{name: "net/http no rule", serve: httpServe(handler), path: "/invoices/42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(recorder.Ended())
+
+			tt.serve(tt.path)
+
+			spans := recorder.Ended()[before:]
+			switch {
+			case tt.sampled == "" && len(spans) != 0:
+				t.Errorf("sampled %d spans, want none", len(spans))
+			case tt.sampled != "" && len(spans) != 1:
+				t.Errorf("sampled %d spans, want 1", len(spans))
+			case tt.sampled != "" && spans[0].Name() != tt.sampled:
+				t.Errorf("span name = %q, want %q", spans[0].Name(), tt.sampled)
+			}
+		})
+	}
+}
+
+func fiberServe(t *testing.T, app *fiber.App) func(path string) {
+	return func(path string) {
+		if _, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil)); err != nil {
+			t.Fatalf("request: %v", err)
+		}
+	}
+}
+
+func httpServe(handler http.Handler) func(path string) {
+	return func(path string) {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+}
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+var knownMethods = map[string]bool{
+	http.MethodConnect: true,
+	http.MethodDelete:  true,
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodPatch:   true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodTrace:   true,
+}
+
+// fiberRequestAttributes returns the HTTP server semantic-convention attributes known
+// when the request arrives.
+func fiberRequestAttributes(c *fiber.Ctx) []attribute.KeyValue {
+	attrs := requestAttributes(c.Method(), c.Path(), c.Protocol(), string(c.Request().Header.Protocol()), c.Hostname())
+
+	if userAgent := c.Get(fiber.HeaderUserAgent); userAgent != "" {
+		attrs = append(attrs, semconv.UserAgentOriginal(userAgent))
+	}
+	if ip := c.IP(); ip != "" {
+		attrs = append(attrs, semconv.ClientAddress(ip))
+	}
+
+	return attrs
+}
+
+// httpRequestAttributes is the net/http counterpart of fiberRequestAttributes.
+func httpRequestAttributes(r *http.Request) []attribute.KeyValue {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	attrs := requestAttributes(r.Method, r.URL.Path, scheme, r.Proto, r.Host)
+
+	if userAgent := r.UserAgent(); userAgent != "" {
+		attrs = append(attrs, semconv.UserAgentOriginal(userAgent))
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		attrs = append(attrs, semconv.ClientAddress(host))
+	}
+
+	return attrs
+}
+
+func requestAttributes(method, path, scheme, protocol, host string) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, 9)
+
+	if knownMethods[method] {
+		attrs = append(attrs, semconv.HTTPRequestMethodKey.String(method))
+	} else {
+		attrs = append(attrs, semconv.HTTPRequestMethodOther, semconv.HTTPRequestMethodOriginal(method))
+	}
+
+	attrs = append(attrs, semconv.URLPath(path), semconv.URLScheme(scheme))
+
+	if _, version, found := strings.Cut(protocol, "/"); found {
+		attrs = append(attrs, semconv.NetworkProtocolVersion(version))
+	}
+
+	if serverAddress, serverPort := splitHost(host); serverAddress != "" {
+		attrs = append(attrs, semconv.ServerAddress(serverAddress))
+		if serverPort > 0 {
+			attrs = append(attrs, semconv.ServerPort(serverPort))
+		}
+	}
+
+	return attrs
+}
+
+func splitHost(host string) (string, int) {
+	address, portStr, err := net.SplitHostPort(host)
+	if err != nil {
+		return host, 0
+	}
+
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return address, 0
+	}
+
+	return address, port
+}
+
+func responseAttributes(route string, statusCode int) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{semconv.HTTPResponseStatusCode(statusCode)}
+	if route != unmatchedRoute && route != unknownRoute {
+		attrs = append(attrs, semconv.HTTPRoute(route))
+	}
+
+	return attrs
+}
+
+// serverSpanStatus follows the HTTP semantic conventions for server spans: only 5xx
+// responses are errors, everything else leaves the status unset.
+func serverSpanStatus(statusCode int) (codes.Code, string) {
+	if statusCode >= http.StatusInternalServerError {
+		return codes.Error, http.StatusText(statusCode)
+	}
+
+	return codes.Unset, ""
+}
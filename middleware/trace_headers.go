@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	traceParentHeader  = "traceparent"
+	serverTimingHeader = "Server-Timing"
+)
+
+// TraceResponseHeaders selects the headers through which the server span is exposed
+// on responses, so clients can report the trace of a failed request. TraceIDHeader
+// names a header carrying the bare trace ID, e.g. "X-Trace-ID"; empty disables it.
+type TraceResponseHeaders struct {
+	TraceParent   bool
+	ServerTiming  bool
+	TraceIDHeader string
+}
+
+func (h TraceResponseHeaders) enabled() bool {
+	return h.TraceParent || h.ServerTiming || h.TraceIDHeader != ""
+}
+
+func setTraceResponseHeaders(c *fiber.Ctx, headers TraceResponseHeaders, spanCtx trace.SpanContext, elapsedTime time.Duration) {
+	if !spanCtx.IsValid() {
+		return
+	}
+
+	traceParent := fmt.Sprintf("00-%s-%s-%s", spanCtx.TraceID(), spanCtx.SpanID(), spanCtx.TraceFlags())
+
+	if headers.TraceParent {
+		c.Set(traceParentHeader, traceParent)
+	}
+
+	if headers.ServerTiming {
+		// The traceparent entry follows the convention browsers' RUM agents use to link
+		// the page load to the backend trace.
+		c.Append(serverTimingHeader,
+			fmt.Sprintf("traceparent;desc=%q", traceParent),
+			fmt.Sprintf("total;dur=%.3f", float64(elapsedTime)/float64(time.Millisecond)),
+		)
+	}
+
+	if headers.TraceIDHeader != "" {
+		c.Set(headers.TraceIDHeader, spanCtx.TraceID().String())
+	}
+}
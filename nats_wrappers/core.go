@@ -0,0 +1,163 @@
+package nats_wrappers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/todesdev/go-obs/internal/logging"
+	natscollector "github.com/todesdev/go-obs/internal/metrics/nats_collector"
+	"github.com/todesdev/go-obs/internal/observer"
+	"github.com/todesdev/go-obs/internal/recovery"
+	"go.uber.org/zap"
+)
+
+// SubscribeCoreWithObservability subscribes to a subject on a plain NATS connection and
+// calls the handler for each message with a consumer span continuing the publisher's
+// trace. ctx is the parent of the per-message contexts. The handler runs on the
+// subscription's delivery goroutine, as with nats.Conn.Subscribe.
+func SubscribeCoreWithObservability(ctx context.Context, nc *nats.Conn, subject string, handler SubscribeHandler) (*nats.Subscription, error) {
+	return nc.Subscribe(subject, func(msg *nats.Msg) {
+		_ = processMessage(ctx, msg, handler, natscollector.NatsSimpleMessageType, nil)
+	})
+}
+
+// QueueSubscribeCoreWithObservability is the queue group counterpart of SubscribeCoreWithObservability.
+func QueueSubscribeCoreWithObservability(ctx context.Context, nc *nats.Conn, subject, queue string, handler SubscribeHandler) (*nats.Subscription, error) {
+	return nc.QueueSubscribe(subject, queue, func(msg *nats.Msg) {
+		_ = processMessage(ctx, msg, handler, natscollector.NatsSimpleMessageType, nil)
+	})
+}
+
+// ChanSubscribeCoreWithObservability delivers messages through a channel of bufferSize
+// messages, so a slow handler is reported as a slow consumer instead of blocking the
+// connection, and calls the handler for each of them on a single goroutine owned by
+// the subscription. When ctx is cancelled the subscription is drained: the messages
+// still pending are handled before the goroutine returns.
+func ChanSubscribeCoreWithObservability(ctx context.Context, nc *nats.Conn, subject string, bufferSize int, handler SubscribeHandler) (*nats.Subscription, error) {
+	ch := make(chan *nats.Msg, bufferSize)
+	sub, err := nc.ChanSubscribe(subject, ch)
+	if err != nil {
+		return nil, err
+	}
+
+	go handleChanSubscription(ctx, sub, ch, handler)
+	return sub, nil
+}
+
+func handleChanSubscription(ctx context.Context, sub *nats.Subscription, ch chan *nats.Msg, handler SubscribeHandler) {
+	logger := logging.LoggerWithProcess("NATS Subscription")
+	closed := sub.StatusChanged(nats.SubscriptionClosed)
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("Context cancelled, draining subscription", zap.String("subject", sub.Subject))
+			if err := sub.Drain(); err != nil && !errors.Is(err, nats.ErrBadSubscription) {
+				logger.Error("Failed to drain subscription", zap.Error(err))
+				return
+			}
+			drainChanSubscription(context.WithoutCancel(ctx), closed, ch, handler)
+			logger.Info("Subscription drained", zap.String("subject", sub.Subject))
+			return
+		case <-closed:
+			logger.Info("Subscription closed", zap.String("subject", sub.Subject))
+			return
+		case msg := <-ch:
+			_ = processMessage(ctx, msg, handler, natscollector.NatsSimpleMessageType, nil)
+		}
+	}
+}
+
+// drainChanSubscription handles the messages delivered to ch until the draining
+// subscription is closed, then those left in ch.
+func drainChanSubscription(ctx context.Context, closed <-chan nats.SubStatus, ch chan *nats.Msg, handler SubscribeHandler) {
+	for {
+		select {
+		case msg := <-ch:
+			_ = processMessage(ctx, msg, handler, natscollector.NatsSimpleMessageType, nil)
+		case <-closed:
+			for {
+				select {
+				case msg := <-ch:
+					_ = processMessage(ctx, msg, handler, natscollector.NatsSimpleMessageType, nil)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// settleFunc settles a processed message, e.g. by acking it, within its consumer span.
+type settleFunc func(obs *observer.Observer, msg *nats.Msg, handlerErr error)
+
+// processMessage calls the handler for msg under a consumer span, recovering panics,
+// records the processing metrics under messageType and settles the message if settle
+// is not nil.
+func processMessage(ctx context.Context, msg *nats.Msg, handler SubscribeHandler, messageType string, settle settleFunc) error {
+	startTime := time.Now()
+	subject := msg.Subject
+
+	obs := observer.ConsumerObserver(messageContext(ctx, msg), "NATS Consumer:"+subject)
+	defer obs.End()
+	obs.LogInfo("NATS Consumer: Received new message", zap.String("subject", subject))
+
+	err := callHandler(handler, msg, obs.Ctx())
+
+	if natsCollector := natscollector.GetNATSCollector(); natsCollector != nil {
+		natsCollector.ProcessedMessagesInc(subject, messageType)
+		natsCollector.ProcessingDurationObserve(subject, messageType, time.Since(startTime))
+	}
+
+	var panicErr *recovery.PanicError
+	switch {
+	case errors.As(err, &panicErr):
+		obs.RecordPanicWithLogging("Message handler panicked", panicErr, panicErr.Stack, zap.String("subject", subject))
+	case err != nil:
+		obs.RecordErrorWithLogging("Error handling the message", err)
+	default:
+		obs.RecordInfoWithLogging("Successfully processed message")
+	}
+
+	if settle != nil {
+		settle(obs, msg, err)
+	}
+
+	return err
+}
+
+// PublishCoreTracedMessage publishes data on a plain NATS connection under a producer
+// span whose context, along with the request ID, is carried in the message headers.
+func PublishCoreTracedMessage(ctx context.Context, nc *nats.Conn, subject string, data []byte) error {
+	return PublishCoreTracedMsg(ctx, nc, &nats.Msg{Subject: subject, Data: data})
+}
+
+// PublishCoreTracedMsg is like PublishCoreTracedMessage for a prepared message, whose
+// headers are kept. msg itself is not modified.
+func PublishCoreTracedMsg(ctx context.Context, nc *nats.Conn, msg *nats.Msg) error {
+	obs := observer.ProducerObserver(ctx, "NATS Producer:"+msg.Subject)
+	defer obs.End()
+
+	obs.LogInfo("NATS Producer: Publishing message", zap.String("subject", msg.Subject))
+
+	out := &nats.Msg{
+		Subject: msg.Subject,
+		Reply:   msg.Reply,
+		Header:  injectHeaders(obs.Ctx(), msg.Header),
+		Data:    msg.Data,
+	}
+
+	if err := nc.PublishMsg(out); err != nil {
+		obs.RecordErrorWithLogging("Error publishing message", err)
+		return err
+	}
+
+	obs.RecordInfoWithLogging("Published message")
+
+	if natsCollector := natscollector.GetNATSCollector(); natsCollector != nil {
+		natsCollector.PublishedMessagesInc(msg.Subject, natscollector.NatsSimpleMessageType)
+	}
+	return nil
+}
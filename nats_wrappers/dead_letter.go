@@ -0,0 +1,80 @@
+package nats_wrappers
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+	natscollector "github.com/todesdev/go-obs/internal/metrics/nats_collector"
+	"github.com/todesdev/go-obs/internal/observer"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.uber.org/zap"
+)
+
+// Headers added to dead-lettered messages, next to the original headers.
+const (
+	DeadLetterErrorHeader           = "X-Dead-Letter-Error"
+	DeadLetterDeliveryCountHeader   = "X-Dead-Letter-Delivery-Count"
+	DeadLetterOriginalSubjectHeader = "X-Dead-Letter-Original-Subject"
+	DeadLetterOriginalStreamHeader  = "X-Dead-Letter-Original-Stream"
+	DeadLetterOriginalSeqHeader     = "X-Dead-Letter-Original-Sequence"
+)
+
+// publishDeadLetter republishes msg, which failed for good with handlerErr, to subject
+// under a producer span that is a child of the consumer span in ctx.
+func publishDeadLetter(ctx context.Context, js nats.JetStream, subject string, msg *nats.Msg, handlerErr error, numDelivered uint64) error {
+	obs := observer.ProducerObserver(ctx, "NATS Dead Letter:"+subject)
+	defer obs.End()
+
+	_, err := js.PublishMsg(&nats.Msg{
+		Subject: subject,
+		Header:  deadLetterHeader(obs.Ctx(), msg, handlerErr, numDelivered),
+		Data:    msg.Data,
+	})
+
+	result := natscollector.NatsDeadLetterResultOK
+	if err != nil {
+		result = natscollector.NatsDeadLetterResultError
+		obs.RecordErrorWithLogging("Failed to publish dead letter", err, zap.String("subject", subject), zap.String("originalSubject", msg.Subject))
+	} else {
+		obs.RecordInfoWithLogging("Published dead letter", zap.String("subject", subject), zap.String("originalSubject", msg.Subject))
+	}
+
+	if natsCollector := natscollector.GetNATSCollector(); natsCollector != nil {
+		natsCollector.DeadLetteredMessagesInc(msg.Subject, result)
+	}
+
+	return err
+}
+
+// deadLetterHeader copies the original headers, replacing the trace context with the
+// one of ctx and dropping the JetStream publish expectations, which refer to the
+// original stream.
+func deadLetterHeader(ctx context.Context, msg *nats.Msg, handlerErr error, numDelivered uint64) nats.Header {
+	header := make(nats.Header, len(msg.Header)+5)
+	for k, vv := range msg.Header {
+		if strings.HasPrefix(k, "Nats-Expected-") {
+			continue
+		}
+		header[k] = append([]string(nil), vv...)
+	}
+
+	carrier := make(propagation.HeaderCarrier)
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	for k, vv := range natsHeader(carrier) {
+		header[k] = vv
+	}
+
+	header.Set(DeadLetterErrorHeader, handlerErr.Error())
+	header.Set(DeadLetterDeliveryCountHeader, strconv.FormatUint(numDelivered, 10))
+	header.Set(DeadLetterOriginalSubjectHeader, msg.Subject)
+
+	if md, err := msg.Metadata(); err == nil {
+		header.Set(DeadLetterOriginalStreamHeader, md.Stream)
+		header.Set(DeadLetterOriginalSeqHeader, strconv.FormatUint(md.Sequence.Stream, 10))
+	}
+
+	return header
+}
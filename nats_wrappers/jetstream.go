@@ -0,0 +1,190 @@
+package nats_wrappers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	natscollector "github.com/todesdev/go-obs/internal/metrics/nats_collector"
+	"github.com/todesdev/go-obs/internal/observer"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultBackoffInitial = time.Second
+	defaultBackoffMax     = time.Minute
+)
+
+// ClassifiedError lets a handler error decide how the JetStream message is settled:
+// retryable errors are nak'ed for redelivery, others are terminated. Errors without a
+// ClassifiedError in their chain are treated as retryable.
+type ClassifiedError interface {
+	error
+	Retryable() bool
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+func (e *permanentError) Retryable() bool {
+	return false
+}
+
+// Permanent marks err as not retryable, so the message is terminated instead of being
+// redelivered.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &permanentError{err: err}
+}
+
+func isRetryable(err error) bool {
+	var classified ClassifiedError
+	if errors.As(err, &classified) {
+		return classified.Retryable()
+	}
+
+	return true
+}
+
+// SubscriberConfig configures how SubscribeWithObservabilityConfig settles messages.
+// Backoff returns the redelivery delay of a message that failed with a retryable error
+// on its numDelivered-th delivery; it defaults to ExponentialBackoff(1s, 1m).
+// MaxDeliver overrides the consumer's MaxDeliver when positive; a message failing on
+// its last allowed delivery is terminated, as a nak would not be redelivered.
+// When DeadLetterSubject is set, terminated messages are first republished to it
+// through JetStream, so a stream must capture that subject.
+//
+// Workers sets how many messages are handled concurrently, one by default, and
+// MaxInFlight how many may be received and not yet settled, at least and by default
+// Workers. Messages with the same OrderingKey, e.g. SubjectOrderingKey, are handled
+// by the same worker in the order they were received.
+type SubscriberConfig struct {
+	Backoff           func(numDelivered uint64) time.Duration
+	MaxDeliver        int
+	DeadLetterSubject string
+	Workers           int
+	MaxInFlight       int
+	OrderingKey       func(msg *nats.Msg) string
+}
+
+// ExponentialBackoff returns a backoff doubling from initial on every delivery, capped at max.
+func ExponentialBackoff(initial, max time.Duration) func(numDelivered uint64) time.Duration {
+	return func(numDelivered uint64) time.Duration {
+		delay := initial
+		for i := uint64(1); i < numDelivered && delay < max; i++ {
+			delay *= 2
+		}
+
+		if delay > max {
+			return max
+		}
+		return delay
+	}
+}
+
+type settler struct {
+	js                nats.JetStream
+	backoff           func(numDelivered uint64) time.Duration
+	maxDeliver        int
+	deadLetterSubject string
+}
+
+func newSettler(cfg SubscriberConfig, js nats.JetStream, sub *nats.Subscription) *settler {
+	s := &settler{
+		js:                js,
+		backoff:           cfg.Backoff,
+		maxDeliver:        cfg.MaxDeliver,
+		deadLetterSubject: cfg.DeadLetterSubject,
+	}
+
+	if s.backoff == nil {
+		s.backoff = ExponentialBackoff(defaultBackoffInitial, defaultBackoffMax)
+	}
+
+	if s.maxDeliver <= 0 {
+		if info, err := sub.ConsumerInfo(); err == nil {
+			s.maxDeliver = info.Config.MaxDeliver
+		}
+	}
+
+	return s
+}
+
+// settle acks, naks or terminates msg according to the handler result and records the
+// outcome. A message the handler settled itself, or one of a consumer with AckNone, is
+// recorded as already settled.
+func (s *settler) settle(obs *observer.Observer, msg *nats.Msg, handlerErr error) {
+	var numDelivered uint64 = 1
+	if md, err := msg.Metadata(); err == nil {
+		numDelivered = md.NumDelivered
+	}
+
+	outcome := s.outcome(handlerErr, numDelivered)
+
+	var err error
+	switch outcome {
+	case natscollector.NatsOutcomeAck:
+		err = msg.Ack()
+	case natscollector.NatsOutcomeTerm, natscollector.NatsOutcomeExhausted:
+		err = s.term(obs, msg, handlerErr, numDelivered)
+	default:
+		err = msg.NakWithDelay(s.backoff(numDelivered))
+	}
+
+	switch {
+	case isAlreadySettled(err):
+		outcome = natscollector.NatsOutcomeAlreadySettled
+	case err != nil:
+		obs.RecordErrorWithLogging("Failed to settle message", err, zap.String("subject", msg.Subject), zap.String("outcome", outcome))
+		outcome = natscollector.NatsOutcomeFailed
+	case outcome != natscollector.NatsOutcomeAck:
+		obs.LogWarning("Message not acknowledged", zap.String("subject", msg.Subject), zap.String("outcome", outcome), zap.Uint64("numDelivered", numDelivered))
+	}
+
+	obs.SetAttributes(attribute.String("messaging.nats.outcome", outcome), attribute.Int64("messaging.nats.num_delivered", int64(numDelivered)))
+
+	if natsCollector := natscollector.GetNATSCollector(); natsCollector != nil {
+		natsCollector.SettledMessagesInc(msg.Subject, natscollector.NatsJetStreamMessageType, outcome)
+	}
+}
+
+// outcome decides how a message on its numDelivered-th delivery is settled.
+func (s *settler) outcome(handlerErr error, numDelivered uint64) string {
+	switch {
+	case handlerErr == nil:
+		return natscollector.NatsOutcomeAck
+	case !isRetryable(handlerErr):
+		return natscollector.NatsOutcomeTerm
+	case s.maxDeliver > 0 && numDelivered >= uint64(s.maxDeliver):
+		return natscollector.NatsOutcomeExhausted
+	default:
+		return natscollector.NatsOutcomeNak
+	}
+}
+
+func isAlreadySettled(err error) bool {
+	return errors.Is(err, nats.ErrMsgAlreadyAckd) || errors.Is(err, nats.ErrCantAckIfConsumerAckNone)
+}
+
+// term terminates msg after republishing it to the dead-letter subject, if configured.
+// A failed dead-letter publish is recorded but does not prevent the termination.
+func (s *settler) term(obs *observer.Observer, msg *nats.Msg, handlerErr error, numDelivered uint64) error {
+	if s.deadLetterSubject != "" {
+		_ = publishDeadLetter(obs.Ctx(), s.js, s.deadLetterSubject, msg, handlerErr, numDelivered)
+	}
+
+	return msg.Term()
+}
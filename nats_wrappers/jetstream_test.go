@@ -0,0 +1,134 @@
+package nats_wrappers
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	natscollector "github.com/todesdev/go-obs/internal/metrics/nats_collector"
+	"github.com/todesdev/go-obs/internal/recovery"
+)
+
+type classified struct {
+	retryable bool
+}
+
+func (e classified) Error() string {
+	return "classified"
+}
+
+func (e classified) Retryable() bool {
+	return e.retryable
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "plain error", err: errors.New("transient"), want: true},
+		{name: "permanent", err: Permanent(errors.New("bad")), want: false},
+		{name: "wrapped permanent", err: fmt.Errorf("handle: %w", Permanent(errors.New("bad"))), want: false},
+		{name: "joined permanent", err: errors.Join(errors.New("a"), Permanent(errors.New("b"))), want: false},
+		{name: "retryable classified", err: classified{retryable: true}, want: true},
+		{name: "non-retryable classified", err: fmt.Errorf("handle: %w", classified{retryable: false}), want: false},
+		{name: "panic", err: &recovery.PanicError{Component: "nats", Value: "boom"}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryable(tt.err); got != tt.want {
+				t.Errorf("isRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPermanentNil(t *testing.T) {
+	if err := Permanent(nil); err != nil {
+		t.Errorf("Permanent(nil) = %v, want nil", err)
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(time.Second, 10*time.Second)
+
+	tests := []struct {
+		numDelivered uint64
+		want         time.Duration
+	}{
+		{numDelivered: 0, want: time.Second},
+		{numDelivered: 1, want: time.Second},
+		{numDelivered: 2, want: 2 * time.Second},
+		{numDelivered: 3, want: 4 * time.Second},
+		{numDelivered: 4, want: 8 * time.Second},
+		{numDelivered: 5, want: 10 * time.Second},
+		{numDelivered: 1000, want: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.numDelivered), func(t *testing.T) {
+			if got := backoff(tt.numDelivered); got != tt.want {
+				t.Errorf("backoff(%d) = %v, want %v", tt.numDelivered, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSettlerOutcome(t *testing.T) {
+	transient := errors.New("transient")
+	permanent := Permanent(errors.New("bad"))
+
+	tests := []struct {
+		name         string
+		maxDeliver   int
+		handlerErr   error
+		numDelivered uint64
+		want         string
+	}{
+		{name: "success", maxDeliver: 3, numDelivered: 1, want: natscollector.NatsOutcomeAck},
+		{name: "success on last delivery", maxDeliver: 3, numDelivered: 3, want: natscollector.NatsOutcomeAck},
+		{name: "retryable", maxDeliver: 3, handlerErr: transient, numDelivered: 1, want: natscollector.NatsOutcomeNak},
+		{name: "retryable before last delivery", maxDeliver: 3, handlerErr: transient, numDelivered: 2, want: natscollector.NatsOutcomeNak},
+		{name: "retryable on last delivery", maxDeliver: 3, handlerErr: transient, numDelivered: 3, want: natscollector.NatsOutcomeExhausted},
+		{name: "retryable past last delivery", maxDeliver: 3, handlerErr: transient, numDelivered: 4, want: natscollector.NatsOutcomeExhausted},
+		{name: "retryable without max deliver", maxDeliver: 0, handlerErr: transient, numDelivered: 100, want: natscollector.NatsOutcomeNak},
+		{name: "unlimited max deliver", maxDeliver: -1, handlerErr: transient, numDelivered: 100, want: natscollector.NatsOutcomeNak},
+		{name: "permanent", maxDeliver: 3, handlerErr: permanent, numDelivered: 1, want: natscollector.NatsOutcomeTerm},
+		{name: "permanent on last delivery", maxDeliver: 3, handlerErr: permanent, numDelivered: 3, want: natscollector.NatsOutcomeTerm},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &settler{maxDeliver: tt.maxDeliver}
+			if got := s.outcome(tt.handlerErr, tt.numDelivered); got != tt.want {
+				t.Errorf("outcome(%v, %d) = %q, want %q", tt.handlerErr, tt.numDelivered, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsAlreadySettled(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "already acked", err: nats.ErrMsgAlreadyAckd, want: true},
+		{name: "ack none", err: nats.ErrCantAckIfConsumerAckNone, want: true},
+		{name: "wrapped already acked", err: fmt.Errorf("ack: %w", nats.ErrMsgAlreadyAckd), want: true},
+		{name: "other", err: nats.ErrTimeout, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isAlreadySettled(tt.err); got != tt.want {
+				t.Errorf("isAlreadySettled(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -7,33 +7,95 @@ import (
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/propagation"
 	"go.uber.org/zap"
-	"time"
+	"net/textproto"
 
 	"github.com/nats-io/nats.go"
 	natscollector "github.com/todesdev/go-obs/internal/metrics/nats_collector"
+	paniccollector "github.com/todesdev/go-obs/internal/metrics/panic_collector"
+	"github.com/todesdev/go-obs/internal/recovery"
+	"github.com/todesdev/go-obs/internal/requestid"
 )
 
 type SubscribeHandler func(msg *nats.Msg, ctxOpts ...context.Context) error
 
 // SubscribeWithObservability subscribes to a subject and calls the handler function for each message received.
-// The handler function is called in a separate goroutine.
+// The handler function is called in a separate goroutine. Messages are settled with the
+// default SubscriberConfig.
 func SubscribeWithObservability(ctx context.Context, stream nats.JetStream, subject, queue string, handler SubscribeHandler, opts ...nats.SubOpt) (*nats.Subscription, error) {
+	subscriber, err := SubscribeWithObservabilityConfig(ctx, stream, subject, queue, handler, SubscriberConfig{}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return subscriber.Subscription, nil
+}
+
+// SubscribeWithObservabilityConfig is like SubscribeWithObservability. Messages are
+// acked when the handler succeeds and nak'ed with backoff or terminated when it fails,
+// as configured by cfg; a failing message never stops the subscription. The wrapper
+// owns acknowledgement, so handlers should return an error, wrapped with Permanent
+// if it is not retryable, rather than settle the message; a message settled by the
+// handler is recorded as already settled.
+//
+// Messages are handled by cfg.Workers goroutines. Once ctx is cancelled no more
+// messages are received and those already received are handled and settled; the
+// returned Subscriber's Done channel is closed then, so callers should wait for it
+// before shutting down observability. The subscription and its consumer are left in
+// place; messages delivered after cancellation are redelivered once their ack wait
+// expires.
+func SubscribeWithObservabilityConfig(ctx context.Context, stream nats.JetStream, subject, queue string, handler SubscribeHandler, cfg SubscriberConfig, opts ...nats.SubOpt) (*Subscriber, error) {
 	sub, err := stream.QueueSubscribeSync(subject, queue, opts...)
 	if err != nil {
 		return nil, err
 	}
-	natsCollector := natscollector.GetNATSCollector()
 
-	go handleSubscription(ctx, sub, handler, natsCollector)
-	return sub, nil
+	subscriber := &Subscriber{Subscription: sub, done: make(chan struct{})}
+	pool := newWorkerPool(ctx, handler, newSettler(cfg, stream, sub).settle, cfg)
+	go func() {
+		defer close(subscriber.done)
+		handleSubscription(ctx, sub, pool)
+	}()
+
+	return subscriber, nil
 }
 
-// handleSubscription handles the subscription and calls the handler function for each message received.
-func handleSubscription(ctx context.Context, sub *nats.Subscription, handler SubscribeHandler, natsCollector *natscollector.NATSCollector) {
+// Subscriber is a JetStream subscription handled by SubscribeWithObservabilityConfig.
+type Subscriber struct {
+	*nats.Subscription
+	done chan struct{}
+}
+
+// Done is closed once the subscription has stopped and every message it received
+// has been handled and settled.
+func (s *Subscriber) Done() <-chan struct{} {
+	return s.done
+}
+
+// Wait blocks until Done is closed or ctx is done, returning the context error in the
+// latter case.
+func (s *Subscriber) Wait(ctx context.Context) error {
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// handleSubscription receives the subscription's messages and dispatches them to the
+// worker pool. When it stops, the pool is drained. The subscription is not
+// unsubscribed, as that would delete the consumer the library created for it.
+func handleSubscription(ctx context.Context, sub *nats.Subscription, pool *workerPool) {
 	logger := logging.LoggerWithProcess("NATS Subscription")
-	for {
+	defer func() {
+		pool.drain()
+		logger.Info("Subscription workers stopped", zap.String("subject", sub.Subject))
+	}()
+
+	for pool.acquire(ctx) {
 		msg, err := sub.NextMsgWithContext(ctx)
 		if err != nil {
+			pool.release()
 			if ctx.Err() != nil {
 				logger.Error("Context cancelled, stopping subscription", zap.Error(err))
 				return
@@ -44,30 +106,30 @@ func handleSubscription(ctx context.Context, sub *nats.Subscription, handler Sub
 			logger.Info("Stopped receiving messages", zap.Error(err))
 			return
 		}
-		startTime := time.Now()
-		subject := msg.Subject
 
-		prop := otel.GetTextMapPropagator()
-		headers := propHeader(msg.Header)
-		ctx = prop.Extract(ctx, headers)
+		pool.dispatch(msg)
+	}
 
-		obs := observer.ConsumerObserver(ctx, "NATS Consumer:"+subject)
-		obs.LogInfo("NATS Consumer: Received new message", zap.String("subject", subject))
+	logger.Error("Context cancelled, stopping subscription", zap.Error(ctx.Err()))
+}
 
-		err = handler(msg, obs.Ctx())
-		if err != nil {
-			elapsedTime := time.Since(startTime)
-			natsCollector.ProcessingDurationObserve(subject, natscollector.NatsJetStreamMessageType, elapsedTime)
-			obs.RecordErrorWithLogging("Error handling the message", err)
-			return
+// callHandler runs the handler, converting a panic into a *recovery.PanicError so a
+// single bad message does not take down the subscription goroutine.
+func callHandler(handler SubscribeHandler, msg *nats.Msg, ctx context.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recovery.Recovered(paniccollector.ComponentNATS, r)
 		}
+	}()
 
-		elapsedTime := time.Since(startTime)
-		natsCollector.ProcessingDurationObserve(subject, natscollector.NatsJetStreamMessageType, elapsedTime)
-		natsCollector.PublishedMessagesInc(subject, natscollector.NatsJetStreamMessageType)
-		obs.RecordInfoWithLogging("Successfully processed message")
-		obs.End()
-	}
+	return handler(msg, ctx)
+}
+
+// messageContext derives the context for processing msg from ctx, carrying the trace
+// context and request ID found in the message headers.
+func messageContext(ctx context.Context, msg *nats.Msg) context.Context {
+	msgCtx := otel.GetTextMapPropagator().Extract(ctx, propHeader(msg.Header))
+	return requestid.NewContext(msgCtx, requestid.Ensure(natsRequestID(msg.Header)))
 }
 
 func PublishTracedMessage(ctx context.Context, js nats.JetStreamContext, subject string, data []byte) error {
@@ -89,17 +151,45 @@ func PublishTracedMessage(ctx context.Context, js nats.JetStreamContext, subject
 }
 
 func newMsg(ctx context.Context, subject string, data []byte) *nats.Msg {
-	prop := otel.GetTextMapPropagator()
-	headers := make(propagation.HeaderCarrier)
-	prop.Inject(ctx, headers)
-
 	return &nats.Msg{
 		Subject: subject,
-		Header:  natsHeader(headers),
+		Header:  injectHeaders(ctx, nil),
 		Data:    data,
 	}
 }
 
+// injectHeaders returns a copy of h with the trace context and request ID of ctx added.
+// The trace context replaces any h already carries, e.g. when republishing a message.
+func injectHeaders(ctx context.Context, h nats.Header) nats.Header {
+	out := make(nats.Header, len(h)+3)
+	for k, vv := range h {
+		out[k] = append([]string(nil), vv...)
+	}
+
+	carrier := make(propagation.HeaderCarrier)
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	for k, vv := range natsHeader(carrier) {
+		out[k] = vv
+	}
+
+	if id := requestid.FromContext(ctx); id != "" && len(out[requestid.Header()]) == 0 {
+		out.Set(requestid.Header(), id)
+	}
+
+	return out
+}
+
+// natsRequestID returns the request ID carried in the message headers. NATS headers
+// are case-sensitive, so the canonical HTTP form used by HTTP-based publishers is
+// checked as well.
+func natsRequestID(h nats.Header) string {
+	if id := h.Get(requestid.Header()); id != "" {
+		return id
+	}
+
+	return h.Get(textproto.CanonicalMIMEHeaderKey(requestid.Header()))
+}
+
 func natsHeader(h propagation.HeaderCarrier) nats.Header {
 	if h == nil {
 		return nil
@@ -0,0 +1,53 @@
+package nats_wrappers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/todesdev/go-obs/internal/requestid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestInjectHeaders(t *testing.T) {
+	previous := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(previous) })
+
+	traceID, _ := trace.TraceIDFromHex("0af7651916cd43dd8448eb211c80319c")
+	spanID, _ := trace.SpanIDFromHex("b7ad6b7169203331")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+	ctx = requestid.NewContext(ctx, "injected-id")
+
+	const stale = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	h := nats.Header{
+		"Traceparent":      []string{stale},
+		"X-Custom":         []string{"kept"},
+		requestid.Header(): []string{"caller-id"},
+	}
+
+	out := injectHeaders(ctx, h)
+
+	if got, want := out.Get("Traceparent"), "00-"+traceID.String()+"-"+spanID.String()+"-01"; got != want {
+		t.Errorf("Traceparent = %q, want %q", got, want)
+	}
+	if got := out.Get("X-Custom"); got != "kept" {
+		t.Errorf("X-Custom = %q, want %q", got, "kept")
+	}
+	if got := out.Get(requestid.Header()); got != "caller-id" {
+		t.Errorf("request ID = %q, want the caller's %q", got, "caller-id")
+	}
+	if got := h.Get("Traceparent"); got != stale {
+		t.Errorf("caller's Traceparent changed to %q", got)
+	}
+
+	if got := injectHeaders(ctx, nil).Get(requestid.Header()); got != "injected-id" {
+		t.Errorf("request ID without headers = %q, want %q", got, "injected-id")
+	}
+}
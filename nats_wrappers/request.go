@@ -0,0 +1,144 @@
+package nats_wrappers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	natscollector "github.com/todesdev/go-obs/internal/metrics/nats_collector"
+	paniccollector "github.com/todesdev/go-obs/internal/metrics/panic_collector"
+	"github.com/todesdev/go-obs/internal/observer"
+	"github.com/todesdev/go-obs/internal/recovery"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.uber.org/zap"
+)
+
+const (
+	// ServiceErrorHeader and ServiceErrorCodeHeader carry handler errors in replies, as
+	// in the NATS micro framework.
+	ServiceErrorHeader     = "Nats-Service-Error"
+	ServiceErrorCodeHeader = "Nats-Service-Error-Code"
+
+	serviceErrorCode = "500"
+)
+
+var messagingSystemNATS = semconv.MessagingSystemKey.String("nats")
+
+// ReplyHandler handles a request received by RespondWithObservability and returns the
+// reply payload.
+type ReplyHandler func(ctx context.Context, msg *nats.Msg) ([]byte, error)
+
+// RequestWithObservability sends a request on a plain NATS connection under a client
+// span whose context is carried in the request headers, and waits up to timeout for
+// the reply; a timeout of zero relies on the ctx deadline. Timeouts and missing
+// responders are recorded as such on the span and in the request latency histogram.
+func RequestWithObservability(ctx context.Context, nc *nats.Conn, subject string, data []byte, timeout time.Duration) (*nats.Msg, error) {
+	startTime := time.Now()
+
+	obs := observer.ClientObserver(ctx, "NATS Request:"+subject)
+	defer obs.End()
+	obs.SetAttributes(messagingSystemNATS, semconv.MessagingDestinationName(subject), semconv.MessagingMessageBodySize(len(data)))
+	obs.LogInfo("NATS Request: Sending request", zap.String("subject", subject))
+
+	reqCtx := obs.Ctx()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		reqCtx, cancel = context.WithTimeout(reqCtx, timeout)
+		defer cancel()
+	}
+
+	reply, err := nc.RequestMsgWithContext(reqCtx, newMsg(obs.Ctx(), subject, data))
+	elapsedTime := time.Since(startTime)
+	result := requestResult(err)
+
+	if natsCollector := natscollector.GetNATSCollector(); natsCollector != nil {
+		natsCollector.RequestDurationObserve(subject, result, elapsedTime)
+	}
+
+	if err != nil {
+		obs.SetAttributes(semconv.ErrorTypeKey.String(result))
+		obs.RecordErrorWithLogging("NATS request failed", err, zap.String("subject", subject), zap.String("result", result), zap.Duration("elapsedTime", elapsedTime))
+		return nil, err
+	}
+
+	obs.RecordInfoWithLogging("Received reply", zap.String("subject", subject), zap.Duration("elapsedTime", elapsedTime))
+	return reply, nil
+}
+
+func requestResult(err error) string {
+	switch {
+	case err == nil:
+		return natscollector.NatsRequestResultOK
+	case errors.Is(err, nats.ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return natscollector.NatsRequestResultTimeout
+	case errors.Is(err, nats.ErrNoResponders):
+		return natscollector.NatsRequestResultNoResponders
+	default:
+		return natscollector.NatsRequestResultError
+	}
+}
+
+// RespondWithObservability handles a request under a server span continuing the
+// requester's trace and replies with the handler's payload, carrying the span context
+// in the reply headers. A handler error or panic is replied with the
+// ServiceErrorHeader set and is also returned. It is meant to be called from a
+// subscription callback:
+//
+//	nc.Subscribe(subject, func(msg *nats.Msg) {
+//		_ = nats_wrappers.RespondWithObservability(ctx, msg, handler)
+//	})
+func RespondWithObservability(ctx context.Context, msg *nats.Msg, handler ReplyHandler) error {
+	startTime := time.Now()
+	subject := msg.Subject
+
+	obs := observer.ServerObserver(messageContext(ctx, msg), "NATS Responder:"+subject)
+	defer obs.End()
+	obs.SetAttributes(messagingSystemNATS, semconv.MessagingDestinationName(subject))
+	obs.LogInfo("NATS Responder: Received request", zap.String("subject", subject))
+
+	data, err := callReplyHandler(handler, msg, obs.Ctx())
+
+	reply := &nats.Msg{Data: data, Header: injectHeaders(obs.Ctx(), nil)}
+	if err != nil {
+		reply.Data = nil
+		reply.Header.Set(ServiceErrorHeader, err.Error())
+		reply.Header.Set(ServiceErrorCodeHeader, serviceErrorCode)
+	}
+
+	if natsCollector := natscollector.GetNATSCollector(); natsCollector != nil {
+		natsCollector.ProcessedMessagesInc(subject, natscollector.NatsSimpleMessageType)
+		natsCollector.ProcessingDurationObserve(subject, natscollector.NatsSimpleMessageType, time.Since(startTime))
+	}
+
+	var panicErr *recovery.PanicError
+	switch {
+	case errors.As(err, &panicErr):
+		// The panic value may contain internals, so the requester gets a generic message.
+		reply.Header.Set(ServiceErrorHeader, "internal error")
+		obs.RecordPanicWithLogging("Request handler panicked", panicErr, panicErr.Stack, zap.String("subject", subject))
+	case err != nil:
+		obs.RecordErrorWithLogging("Error handling the request", err, zap.String("subject", subject))
+	}
+
+	if respondErr := msg.RespondMsg(reply); respondErr != nil {
+		obs.RecordErrorWithLogging("Error sending reply", respondErr, zap.String("subject", subject))
+		return errors.Join(err, respondErr)
+	}
+
+	if err == nil {
+		obs.RecordInfoWithLogging("Sent reply", zap.String("subject", subject))
+	}
+
+	return err
+}
+
+func callReplyHandler(handler ReplyHandler, msg *nats.Msg, ctx context.Context) (data []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recovery.Recovered(paniccollector.ComponentNATS, r)
+		}
+	}()
+
+	return handler(ctx, msg)
+}
@@ -0,0 +1,124 @@
+package nats_wrappers
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	natscollector "github.com/todesdev/go-obs/internal/metrics/nats_collector"
+)
+
+// SubjectOrderingKey keeps the messages of each subject in order.
+func SubjectOrderingKey(msg *nats.Msg) string {
+	return msg.Subject
+}
+
+type workItem struct {
+	msg      *nats.Msg
+	received time.Time
+}
+
+// workerPool handles the messages of a subscription with a fixed number of workers.
+// slots bounds the messages received and not yet settled. Without an ordering key
+// all workers share a single queue, otherwise each worker has its own and a message
+// goes to the worker its key hashes to.
+type workerPool struct {
+	ctx         context.Context
+	handler     SubscribeHandler
+	settle      settleFunc
+	orderingKey func(msg *nats.Msg) string
+	slots       chan struct{}
+	queues      []chan workItem
+	wg          sync.WaitGroup
+}
+
+func newWorkerPool(ctx context.Context, handler SubscribeHandler, settle settleFunc, cfg SubscriberConfig) *workerPool {
+	workers := max(cfg.Workers, 1)
+	maxInFlight := max(cfg.MaxInFlight, workers)
+
+	queues := 1
+	if cfg.OrderingKey != nil {
+		queues = workers
+	}
+
+	p := &workerPool{
+		// Messages already received are handled to the end when ctx is cancelled.
+		ctx:         context.WithoutCancel(ctx),
+		handler:     handler,
+		settle:      settle,
+		orderingKey: cfg.OrderingKey,
+		slots:       make(chan struct{}, maxInFlight),
+		queues:      make([]chan workItem, queues),
+	}
+
+	for i := range p.queues {
+		// A queue never holds more than maxInFlight messages, so dispatch does not block.
+		p.queues[i] = make(chan workItem, maxInFlight)
+	}
+
+	for i := 0; i < workers; i++ {
+		p.wg.Add(1)
+		go p.work(p.queues[i%queues])
+	}
+
+	return p
+}
+
+// acquire waits for a free in-flight slot and reports false if ctx is cancelled first.
+func (p *workerPool) acquire(ctx context.Context) bool {
+	select {
+	case p.slots <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (p *workerPool) release() {
+	<-p.slots
+}
+
+// dispatch queues msg, which holds an in-flight slot, for a worker.
+func (p *workerPool) dispatch(msg *nats.Msg) {
+	if natsCollector := natscollector.GetNATSCollector(); natsCollector != nil {
+		natsCollector.InFlightMessagesInc(msg.Subject, natscollector.NatsJetStreamMessageType)
+	}
+
+	queue := p.queues[0]
+	if p.orderingKey != nil {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(p.orderingKey(msg)))
+		queue = p.queues[h.Sum32()%uint32(len(p.queues))]
+	}
+
+	queue <- workItem{msg: msg, received: time.Now()}
+}
+
+func (p *workerPool) work(queue <-chan workItem) {
+	defer p.wg.Done()
+
+	for item := range queue {
+		natsCollector := natscollector.GetNATSCollector()
+		if natsCollector != nil {
+			natsCollector.QueueWaitObserve(item.msg.Subject, natscollector.NatsJetStreamMessageType, time.Since(item.received))
+		}
+
+		_ = processMessage(p.ctx, item.msg, p.handler, natscollector.NatsJetStreamMessageType, p.settle)
+
+		if natsCollector != nil {
+			natsCollector.InFlightMessagesDec(item.msg.Subject, natscollector.NatsJetStreamMessageType)
+		}
+		p.release()
+	}
+}
+
+// drain stops accepting messages and waits for the workers to settle the queued ones.
+func (p *workerPool) drain() {
+	for _, queue := range p.queues {
+		close(queue)
+	}
+
+	p.wg.Wait()
+}
@@ -3,6 +3,8 @@ package goobs
 import (
 	"context"
 	"errors"
+	"net/http"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	"github.com/prometheus/client_golang/prometheus"
@@ -11,6 +13,7 @@ import (
 	"github.com/todesdev/go-obs/internal/logging"
 	"github.com/todesdev/go-obs/internal/metrics"
 	"github.com/todesdev/go-obs/internal/observer"
+	"github.com/todesdev/go-obs/internal/requestid"
 	"github.com/todesdev/go-obs/internal/tracing"
 	"github.com/todesdev/go-obs/middleware"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
@@ -21,6 +24,10 @@ import (
 	"google.golang.org/grpc"
 )
 
+// Config configures Initialize. FiberApp is optional: when set, the observability
+// middleware and the metrics handler are registered on it. When MetricsServerAddress
+// is set, metrics are additionally served by a standalone net/http server, which is
+// how services without an HTTP framework (gRPC or NATS workers) expose them.
 type Config struct {
 	FiberApp               *fiber.App
 	ServiceName            string
@@ -28,88 +35,175 @@ type Config struct {
 	Region                 string
 	LogLevel               string
 	OTLPGRPCEndpoint       string
+	OTLPHTTPEndpoint       string
+	OTLPHTTPEncoding       string
+	OTLPConnection         OTLPConnectionConfig
 	TracingEnabled         bool
+	Sampling               SamplingConfig
 	MetricsEnabled         bool
 	MetricsHandlerEndpoint string
+	MetricsServerAddress   string
 	MetricsHTTP            bool
+	MetricsHTTPClient      bool
 	MetricsGRPC            bool
 	MetricsNATS            bool
+	// HTTPErrorMapper optionally translates Fiber handler errors before they reach the
+	// app's ErrorHandler.
+	HTTPErrorMapper middleware.ErrorMapper
+	// HTTPFilters select Fiber and net/http requests for which tracing, metrics or
+	// logging are skipped. When nil, /metrics, /health and /ready are skipped. The configured
+	// MetricsHandlerEndpoint is always skipped.
+	HTTPFilters []middleware.Filter
+	// HTTPTraceResponseHeaders selects the headers exposing the trace of a Fiber
+	// request on its response.
+	HTTPTraceResponseHeaders middleware.TraceResponseHeaders
+	// RequestIDHeader names the header carrying the request ID in HTTP requests and
+	// NATS messages; it is lower-cased for gRPC metadata. Defaults to X-Request-ID.
+	RequestIDHeader string
 }
 
 func Initialize(config *Config) error {
 	validatedConfig, err := validateConfig(config)
 	if err != nil {
-		return err
+		return newSetupError(ErrInvalidConfig, err)
 	}
 
-	logging.Setup(validatedConfig.Region, validatedConfig.ServiceName, validatedConfig.ServiceVersion, validatedConfig.LogLevel)
+	err = logging.Setup(validatedConfig.Region, validatedConfig.ServiceName, validatedConfig.ServiceVersion, validatedConfig.LogLevel)
+	if err != nil {
+		return newSetupError(ErrLoggingSetup, err)
+	}
 
 	logger := logging.LoggerWithProcess("observability:initialize")
 	logger.Info("Logger setup complete")
 
+	requestid.SetHeader(validatedConfig.RequestIDHeader)
+	httpMiddlewareConfig = middleware.HTTPConfig{
+		Filters:         validatedConfig.HTTPFilters,
+		MetricsEndpoint: validatedConfig.MetricsHandlerEndpoint,
+	}
+
 	if validatedConfig.TracingEnabled {
+		err := setupTracing(logger, tracingConfig{
+			serviceName:      validatedConfig.ServiceName,
+			serviceVersion:   validatedConfig.ServiceVersion,
+			region:           validatedConfig.Region,
+			otlpGRPCEndpoint: validatedConfig.OTLPGRPCEndpoint,
+			otlpHTTPEndpoint: validatedConfig.OTLPHTTPEndpoint,
+			otlpHTTPEncoding: validatedConfig.OTLPHTTPEncoding,
+			otlpConnection:   validatedConfig.OTLPConnection,
+			sampling:         validatedConfig.Sampling,
+		})
+		if err != nil {
+			return newSetupError(ErrTracingSetup, err)
+		}
+
 		observer.SetTracingEnabled(true)
-		res, err := registerResource(validatedConfig.ServiceName, validatedConfig.ServiceVersion, validatedConfig.Region)
+		logger.Info("Tracing setup complete")
+	} else {
+		logger.Warn("Tracing is disabled")
+	}
+
+	var promRegistry *prometheus.Registry
+	if validatedConfig.MetricsEnabled {
+		promRegistry, err = metrics.Setup(validatedConfig.ServiceName, metrics.Collectors{
+			HTTP:            validatedConfig.MetricsHTTP,
+			HTTPClient:      validatedConfig.MetricsHTTPClient,
+			GRPC:            validatedConfig.MetricsGRPC,
+			NATS:            validatedConfig.MetricsNATS,
+			TracingExporter: validatedConfig.TracingEnabled,
+		})
 		if err != nil {
-			logger.Error("Failed to register resource", zap.Error(err))
-			return err
+			logger.Error("Failed to setup metrics", zap.Error(err))
+			releaseTracing(logger)
+			return newSetupError(ErrMetricsSetup, err)
 		}
+	}
 
-		if validatedConfig.OTLPGRPCEndpoint != "" {
-			err := tracing.SetupOtlpGrpcTracer(validatedConfig.OTLPGRPCEndpoint, validatedConfig.ServiceName, res)
-			if err != nil {
-				logger.Error("Failed to setup OTLP GRPC tracer", zap.Error(err))
-				logger.Info("Switching to STDOUT tracer")
-				err = tracing.SetupStdOutTracer(validatedConfig.ServiceName, res)
-				if err != nil {
-					logger.Error("Failed to setup STDOUT tracer", zap.Error(err))
-					return err
-				}
+	if validatedConfig.FiberApp != nil {
+		registerFiberMiddleware(validatedConfig.FiberApp, middleware.Config{
+			TracingEnabled:       validatedConfig.TracingEnabled,
+			MetricsEnabled:       validatedConfig.MetricsEnabled && validatedConfig.MetricsHTTP,
+			ErrorMapper:          validatedConfig.HTTPErrorMapper,
+			Filters:              validatedConfig.HTTPFilters,
+			MetricsEndpoint:      validatedConfig.MetricsHandlerEndpoint,
+			TraceResponseHeaders: validatedConfig.HTTPTraceResponseHeaders,
+		})
+	}
 
-				logger.Info("Tracing exporter set to STDOUT")
-			}
-			logger.Info("Tracing exporter set to OTLP GRPC")
-		} else {
-			err := tracing.SetupStdOutTracer(validatedConfig.ServiceName, res)
+	if validatedConfig.MetricsEnabled {
+		if validatedConfig.FiberApp != nil {
+			registerFiberMetricsHandler(validatedConfig.FiberApp, promRegistry, validatedConfig.MetricsHandlerEndpoint)
+		}
 
+		if validatedConfig.MetricsServerAddress != "" {
+			err := metrics.Serve(promRegistry, validatedConfig.MetricsServerAddress, validatedConfig.MetricsHandlerEndpoint)
 			if err != nil {
-				logger.Error("Failed to setup STDOUT tracer", zap.Error(err))
-				return err
+				logger.Error("Failed to start metrics server", zap.Error(err))
+				releaseTracing(logger)
+				if err := metrics.Shutdown(context.Background()); err != nil {
+					logger.Error("Failed to shutdown metrics", zap.Error(err))
+				}
+				return newSetupError(ErrMetricsSetup, err)
 			}
+		}
 
-			logger.Info("Tracing exporter set to STDOUT")
+		if validatedConfig.FiberApp == nil && validatedConfig.MetricsServerAddress == "" {
+			logger.Warn("Metrics are collected but not exposed: neither a Fiber app nor a metrics server address is configured")
 		}
 
-		logger.Info("Tracing setup complete")
+		logger.Info("Metrics setup complete")
 	} else {
-		logger.Warn("Tracing is disabled")
+		logger.Warn("Metrics are disabled")
 	}
 
-	if validatedConfig.MetricsEnabled {
-		promRegistry := &prometheus.Registry{}
+	return nil
+}
 
-		promRegistry = metrics.Setup(validatedConfig.ServiceName, validatedConfig.MetricsHTTP, validatedConfig.MetricsGRPC, validatedConfig.MetricsNATS)
+// releaseTracing shuts down the tracer provider and collector connection when a later
+// setup stage fails, as the caller gets no handle to do it.
+func releaseTracing(logger *logging.Logger) {
+	observer.SetTracingEnabled(false)
+	if err := tracing.Shutdown(context.Background()); err != nil {
+		logger.Error("Failed to shutdown tracing", zap.Error(err))
+	}
+}
 
-		registerFiberMiddleware(validatedConfig.FiberApp, validatedConfig.TracingEnabled, validatedConfig.MetricsEnabled)
-		registerFiberMetricsHandler(validatedConfig.FiberApp, promRegistry, validatedConfig.MetricsHandlerEndpoint)
+// Shutdown flushes pending spans, closes the OTLP collector connection, stops the
+// standalone metrics server, unregisters the metrics collectors and syncs the logger.
+// It should be called once before the service exits; the context deadline bounds the
+// span flush.
+func Shutdown(ctx context.Context) error {
+	logger := logging.LoggerWithProcess("observability:shutdown")
+	logger.Info("Shutting down observability")
+
+	var errs []error
+	if err := tracing.Shutdown(ctx); err != nil {
+		logger.Error("Failed to shutdown tracing", zap.Error(err))
+		errs = append(errs, err)
+	}
 
-		logger.Info("Metrics setup complete")
-	} else {
-		logger.Warn("Metrics are disabled")
+	if err := metrics.Shutdown(ctx); err != nil {
+		logger.Error("Failed to shutdown metrics", zap.Error(err))
+		errs = append(errs, err)
 	}
 
-	return nil
+	logger.Info("Observability shutdown complete")
+
+	if err := logging.Sync(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
 }
 
 func validateConfig(cfg *Config) (*Config, error) {
 	var validatedConfig Config
 
-	if cfg.FiberApp == nil {
-		return nil, errors.New("fiber app is nil")
-	} else {
-		validatedConfig.FiberApp = cfg.FiberApp
+	if cfg == nil {
+		return nil, errors.New("config is nil")
 	}
 
+	validatedConfig.FiberApp = cfg.FiberApp
 	validatedConfig.ServiceName = cfg.ServiceName
 	validatedConfig.ServiceVersion = cfg.ServiceVersion
 	validatedConfig.Region = cfg.Region
@@ -117,10 +211,25 @@ func validateConfig(cfg *Config) (*Config, error) {
 	validatedConfig.TracingEnabled = cfg.TracingEnabled
 	validatedConfig.MetricsEnabled = cfg.MetricsEnabled
 	validatedConfig.MetricsHTTP = cfg.MetricsHTTP
+	validatedConfig.MetricsHTTPClient = cfg.MetricsHTTPClient
 	validatedConfig.MetricsGRPC = cfg.MetricsGRPC
 	validatedConfig.MetricsNATS = cfg.MetricsNATS
+	validatedConfig.HTTPErrorMapper = cfg.HTTPErrorMapper
+	validatedConfig.HTTPFilters = cfg.HTTPFilters
+	validatedConfig.RequestIDHeader = cfg.RequestIDHeader
+	validatedConfig.HTTPTraceResponseHeaders = cfg.HTTPTraceResponseHeaders
 
 	validatedConfig.OTLPGRPCEndpoint = cfg.OTLPGRPCEndpoint
+	validatedConfig.OTLPHTTPEndpoint = cfg.OTLPHTTPEndpoint
+	validatedConfig.OTLPHTTPEncoding = cfg.OTLPHTTPEncoding
+	validatedConfig.OTLPConnection = cfg.OTLPConnection
+	validatedConfig.Sampling = cfg.Sampling
+
+	validatedConfig.MetricsServerAddress = cfg.MetricsServerAddress
+
+	if cfg.RequestIDHeader == "" {
+		validatedConfig.RequestIDHeader = requestid.DefaultHeader
+	}
 
 	if cfg.MetricsHandlerEndpoint == "" {
 		validatedConfig.MetricsHandlerEndpoint = "/metrics"
@@ -143,19 +252,22 @@ func registerResource(serviceName, serviceVersion, region string) (*resource.Res
 
 func GRPCClientInterceptors() []grpc.DialOption {
 	return []grpc.DialOption{
-		grpc.WithStatsHandler(otelgrpc.NewClientHandler())}
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+		grpc.WithChainUnaryInterceptor(interceptors.UnaryClientInterceptor()),
+		grpc.WithChainStreamInterceptor(interceptors.StreamClientInterceptor()),
+	}
 }
 
 func GRPCServerInterceptors() []grpc.ServerOption {
 	return []grpc.ServerOption{
 		grpc.StatsHandler(otelgrpc.NewServerHandler()),
-		grpc.UnaryInterceptor(interceptors.UnaryServerInterceptor()),
-		grpc.StreamInterceptor(interceptors.StreamServerInterceptor()),
+		grpc.ChainUnaryInterceptor(interceptors.UnaryServerInterceptor(), interceptors.ObserverUnaryServerInterceptor(), interceptors.RecoveryUnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(interceptors.StreamServerInterceptor(), interceptors.ObserverStreamServerInterceptor(), interceptors.RecoveryStreamServerInterceptor()),
 	}
 }
 
-func registerFiberMiddleware(fiberApp *fiber.App, tracingEnabled bool, metricsEnabled bool) {
-	fiberApp.Use(middleware.Observability(tracingEnabled, metricsEnabled))
+func registerFiberMiddleware(fiberApp *fiber.App, cfg middleware.Config) {
+	fiberApp.Use(middleware.ObservabilityWithConfig(cfg))
 }
 
 func registerFiberMetricsHandler(fiberApp *fiber.App, registry *prometheus.Registry, metricsEndpoint string) {
@@ -163,6 +275,25 @@ func registerFiberMetricsHandler(fiberApp *fiber.App, registry *prometheus.Regis
 	fiberApp.Get(metricsEndpoint, metricsHandler)
 }
 
+// MetricsHandler returns an http.Handler exposing the metrics registry, for services
+// that mount it on their own net/http mux instead of a Fiber app.
+func MetricsHandler() http.Handler {
+	return metrics.Handler()
+}
+
+// httpMiddlewareConfig holds the skip rules set by Initialize for HTTPMiddleware.
+var httpMiddlewareConfig middleware.HTTPConfig
+
+// HTTPMiddleware returns the net/http observability middleware, equivalent to the
+// one Initialize registers on a Fiber app. It applies the configured HTTPFilters and
+// MetricsHandlerEndpoint, so it should be created after Initialize.
+func HTTPMiddleware(tracingEnabled bool, metricsEnabled bool) func(http.Handler) http.Handler {
+	cfg := httpMiddlewareConfig
+	cfg.TracingEnabled = tracingEnabled
+	cfg.MetricsEnabled = metricsEnabled
+	return middleware.HTTPObservabilityWithConfig(cfg)
+}
+
 func NewInternalTrace(ctx context.Context, processName string) (context.Context, trace.Span) {
 	return tracing.NewInternalTrace(ctx, processName)
 }
@@ -3,9 +3,22 @@ package goobs
 import (
 	"context"
 	"github.com/todesdev/go-obs/internal/observer"
+	"github.com/todesdev/go-obs/internal/requestid"
 	"runtime"
 )
 
+// ObserverFromContext returns the Observer attached to ctx by the gRPC server
+// interceptors, or nil if there is none.
+func ObserverFromContext(ctx context.Context) *observer.Observer {
+	return observer.FromContext(ctx)
+}
+
+// RequestIDFromContext returns the request ID the HTTP middleware, gRPC interceptors or
+// NATS consumer stored in ctx, or an empty string.
+func RequestIDFromContext(ctx context.Context) string {
+	return requestid.FromContext(ctx)
+}
+
 func InternalObserver(ctx context.Context, process ...string) *observer.Observer {
 	var p string
 	if len(process) > 0 {
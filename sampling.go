@@ -0,0 +1,40 @@
+package goobs
+
+import (
+	"github.com/todesdev/go-obs/internal/tracing"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	SamplerAlwaysOn                = tracing.SamplerAlwaysOn
+	SamplerAlwaysOff               = tracing.SamplerAlwaysOff
+	SamplerTraceIDRatio            = tracing.SamplerTraceIDRatio
+	SamplerParentBasedAlwaysOn     = tracing.SamplerParentBasedAlwaysOn
+	SamplerParentBasedAlwaysOff    = tracing.SamplerParentBasedAlwaysOff
+	SamplerParentBasedTraceIDRatio = tracing.SamplerParentBasedTraceIDRatio
+)
+
+// SamplingConfig selects how spans are sampled. An empty Strategy samples every span.
+// Ratio is used by the traceidratio strategies. Rules are matched against span names
+// in order and override the strategy for matching spans.
+type SamplingConfig struct {
+	Strategy string
+	Ratio    float64
+	Rules    []SamplingRule
+}
+
+// SamplingRule samples spans whose name matches Pattern (path.Match syntax, e.g.
+// "HTTP:GET:/health" or "NATS Consumer:orders.*") with the given Ratio.
+type SamplingRule struct {
+	Pattern string
+	Ratio   float64
+}
+
+func newSampler(cfg SamplingConfig) (sdktrace.Sampler, error) {
+	rules := make([]tracing.SamplingRule, 0, len(cfg.Rules))
+	for _, rule := range cfg.Rules {
+		rules = append(rules, tracing.SamplingRule{Pattern: rule.Pattern, Ratio: rule.Ratio})
+	}
+
+	return tracing.NewSampler(cfg.Strategy, cfg.Ratio, rules)
+}
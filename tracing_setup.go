@@ -0,0 +1,111 @@
+package goobs
+
+import (
+	"time"
+
+	"github.com/todesdev/go-obs/internal/logging"
+	"github.com/todesdev/go-obs/internal/tracing"
+	"go.uber.org/zap"
+)
+
+const (
+	OTLPHTTPEncodingProtobuf = tracing.HttpEncodingProtobuf
+	OTLPHTTPEncodingJSON     = tracing.HttpEncodingJSON
+
+	OTLPCompressionNone = tracing.CompressionNone
+	OTLPCompressionGzip = tracing.CompressionGzip
+)
+
+// OTLPConnectionConfig configures the connection to the OTLP collector for both the
+// gRPC and HTTP exporters. TLS is enabled by TLSEnabled or by setting any certificate
+// path; without CACertFile the host's root CAs are used. ClientCertFile and
+// ClientKeyFile enable mTLS. Timeout bounds each connection attempt and each export.
+// The collector does not need to be reachable at startup: the connection is retried in
+// the background and failed exports are retried with exponential backoff, bounded by
+// the Retry* settings (zero values use 5s initial, 30s max interval, 1m total).
+type OTLPConnectionConfig struct {
+	TLSEnabled           bool
+	CACertFile           string
+	ClientCertFile       string
+	ClientKeyFile        string
+	Headers              map[string]string
+	Compression          string
+	Timeout              time.Duration
+	RetryDisabled        bool
+	RetryInitialInterval time.Duration
+	RetryMaxInterval     time.Duration
+	RetryMaxElapsedTime  time.Duration
+}
+
+func (c OTLPConnectionConfig) options() tracing.ConnectionOptions {
+	return tracing.ConnectionOptions{
+		TLSEnabled:           c.TLSEnabled,
+		CACertFile:           c.CACertFile,
+		ClientCertFile:       c.ClientCertFile,
+		ClientKeyFile:        c.ClientKeyFile,
+		Headers:              c.Headers,
+		Compression:          c.Compression,
+		Timeout:              c.Timeout,
+		RetryDisabled:        c.RetryDisabled,
+		RetryInitialInterval: c.RetryInitialInterval,
+		RetryMaxInterval:     c.RetryMaxInterval,
+		RetryMaxElapsedTime:  c.RetryMaxElapsedTime,
+	}
+}
+
+type tracingConfig struct {
+	serviceName      string
+	serviceVersion   string
+	region           string
+	otlpGRPCEndpoint string
+	otlpHTTPEndpoint string
+	otlpHTTPEncoding string
+	otlpConnection   OTLPConnectionConfig
+	sampling         SamplingConfig
+}
+
+// setupTracing installs the OTLP gRPC exporter when a gRPC endpoint is configured,
+// otherwise the OTLP HTTP exporter when an HTTP endpoint is configured. If the OTLP
+// exporter cannot be set up, or no endpoint is configured, spans are written to STDOUT.
+func setupTracing(logger *logging.Logger, cfg tracingConfig) error {
+	res, err := registerResource(cfg.serviceName, cfg.serviceVersion, cfg.region)
+	if err != nil {
+		logger.Error("Failed to register resource", zap.Error(err))
+		return err
+	}
+
+	sampler, err := newSampler(cfg.sampling)
+	if err != nil {
+		logger.Error("Failed to configure sampler", zap.Error(err))
+		return err
+	}
+
+	switch {
+	case cfg.otlpGRPCEndpoint != "":
+		err := tracing.SetupOtlpGrpcTracer(cfg.otlpGRPCEndpoint, cfg.serviceName, res, sampler, cfg.otlpConnection.options())
+		if err == nil {
+			logger.Info("Tracing exporter set to OTLP GRPC")
+			return nil
+		}
+
+		logger.Error("Failed to setup OTLP GRPC tracer", zap.Error(err))
+		logger.Info("Switching to STDOUT tracer")
+	case cfg.otlpHTTPEndpoint != "":
+		err := tracing.SetupOtlpHttpTracer(cfg.otlpHTTPEndpoint, cfg.otlpHTTPEncoding, cfg.serviceName, res, sampler, cfg.otlpConnection.options())
+		if err == nil {
+			logger.Info("Tracing exporter set to OTLP HTTP")
+			return nil
+		}
+
+		logger.Error("Failed to setup OTLP HTTP tracer", zap.Error(err))
+		logger.Info("Switching to STDOUT tracer")
+	}
+
+	if err := tracing.SetupStdOutTracer(cfg.serviceName, res, sampler); err != nil {
+		logger.Error("Failed to setup STDOUT tracer", zap.Error(err))
+		return err
+	}
+
+	logger.Info("Tracing exporter set to STDOUT")
+	return nil
+}